@@ -0,0 +1,246 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+//
+// Public types
+//
+
+// Attributes holds arbitrary values that are attached to a File as it moves through a pipeline (e.g., the digests
+// computed while the File is read).  Attributes are safe for concurrent use.  Values should be able to be marshalled to
+// JSON, since Attributes are included in resultProduced events and can be persisted using WriteAttributesSidecar().
+type Attributes interface {
+	Get(key string) (interface{}, bool)
+
+	// Keys returns the keys of every attribute, sorted.
+	Keys() []string
+
+	Set(key string, value interface{})
+}
+
+//
+// Public constants
+//
+
+// AttributesSidecarExtension is appended to the path of a File to produce the path of the sidecar file that holds its
+// Attributes (see WriteAttributesSidecar()).
+const AttributesSidecarExtension = ".attributes.json"
+
+//
+// Public functions
+//
+
+// AttributesSidecarPath returns the path of the sidecar file that holds the Attributes of the file at the given path.
+func AttributesSidecarPath(path string) string {
+	return path + AttributesSidecarExtension
+}
+
+// GetAttributeBool retrieves an attribute that holds a bool.  ok is false if the attribute isn't set or holds a value
+// of another type.
+func GetAttributeBool(attrs Attributes, key string) (value bool, ok bool) {
+	var raw interface{}
+
+	if raw, ok = attrs.Get(key); !ok {
+		return false, false
+	}
+
+	value, ok = raw.(bool)
+
+	return value, ok
+}
+
+// GetAttributeInt64 retrieves an attribute that holds an integer.  Any integer type is accepted, as is a float64 with
+// no fractional part, which is what integers become after a round trip through a sidecar file.  ok is false if the
+// attribute isn't set, holds a value of another type, or holds a value that doesn't fit in an int64.
+func GetAttributeInt64(attrs Attributes, key string) (int64, bool) {
+	var raw, ok = attrs.Get(key)
+
+	if !ok {
+		return 0, false
+	}
+
+	switch value := raw.(type) {
+	case int:
+		return int64(value), true
+
+	case int8:
+		return int64(value), true
+
+	case int16:
+		return int64(value), true
+
+	case int32:
+		return int64(value), true
+
+	case int64:
+		return value, true
+
+	case uint:
+		if uint64(value) <= math.MaxInt64 {
+			return int64(value), true
+		}
+
+	case uint8:
+		return int64(value), true
+
+	case uint16:
+		return int64(value), true
+
+	case uint32:
+		return int64(value), true
+
+	case uint64:
+		if value <= math.MaxInt64 {
+			return int64(value), true
+		}
+
+	case float64:
+		// float64(math.MaxInt64) rounds up to 2^63, which doesn't fit.
+
+		if value >= math.MinInt64 && value < math.MaxInt64 && value == math.Trunc(value) {
+			return int64(value), true
+		}
+	}
+
+	return 0, false
+}
+
+// GetAttributeString retrieves an attribute that holds a string.  ok is false if the attribute isn't set or holds a
+// value of another type.
+func GetAttributeString(attrs Attributes, key string) (value string, ok bool) {
+	var raw interface{}
+
+	if raw, ok = attrs.Get(key); !ok {
+		return "", false
+	}
+
+	value, ok = raw.(string)
+
+	return value, ok
+}
+
+// GetAttributeTime retrieves an attribute that holds a time.Time.  A string in RFC 3339 format is also accepted, which
+// is what a time.Time becomes after a round trip through a sidecar file.
+func GetAttributeTime(attrs Attributes, key string) (time.Time, bool) {
+	var raw, ok = attrs.Get(key)
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch value := raw.(type) {
+	case time.Time:
+		return value, true
+
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// ReadAttributesSidecar reads Attributes previously written by WriteAttributesSidecar() and sets them on a File.  Since
+// the sidecar is JSON, values are restored as the types produced by encoding/json (e.g., numbers become float64s), so
+// the GetAttribute functions should be used to retrieve them.
+func ReadAttributesSidecar(reader io.Reader, file File) error {
+	var values map[string]interface{}
+
+	if err := json.NewDecoder(reader).Decode(&values); err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		file.Attributes().Set(key, value)
+	}
+
+	return nil
+}
+
+// WriteAttributesSidecar writes the Attributes of a File as a JSON object, which is meant to be stored alongside the
+// File's contents (see AttributesSidecarPath()).  Every value must be able to be marshalled to JSON.
+func WriteAttributesSidecar(writer io.Writer, file File) error {
+	var encoder = json.NewEncoder(writer)
+
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(attributesToMap(file.Attributes()))
+}
+
+//
+// Private types
+//
+
+// Attributes implementation
+type attributes struct {
+	mutex  sync.RWMutex
+	values map[string]interface{}
+}
+
+func (attrs *attributes) Get(key string) (interface{}, bool) {
+	var ok bool
+	var value interface{}
+
+	attrs.mutex.RLock()
+	defer attrs.mutex.RUnlock()
+
+	value, ok = attrs.values[key]
+
+	return value, ok
+}
+
+func (attrs *attributes) Keys() []string {
+	var keys []string
+
+	attrs.mutex.RLock()
+
+	keys = make([]string, 0, len(attrs.values))
+
+	for key := range attrs.values {
+		keys = append(keys, key)
+	}
+
+	attrs.mutex.RUnlock()
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (attrs *attributes) Set(key string, value interface{}) {
+	attrs.mutex.Lock()
+	defer attrs.mutex.Unlock()
+
+	attrs.values[key] = value
+}
+
+//
+// Private functions
+//
+
+// Copies Attributes into a map so that they can be marshalled or attached to an Event.
+func attributesToMap(attrs Attributes) map[string]interface{} {
+	var values = make(map[string]interface{})
+
+	for _, key := range attrs.Keys() {
+		if value, ok := attrs.Get(key); ok {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
+func newAttributes() *attributes {
+	return &attributes{
+		values: make(map[string]interface{}),
+	}
+}
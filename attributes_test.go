@@ -0,0 +1,225 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// Attributes tests
+
+var _ = g.Describe("Attributes", func() {
+	g.Describe("given a new instance", func() {
+		var attrs Attributes
+
+		g.BeforeEach(func() {
+			attrs = newAttributes()
+		})
+
+		g.Describe("calling GetAttributeBool", func() {
+			g.It("should return the value only if it is a bool", func() {
+				var ok bool
+				var value bool
+
+				attrs.Set("bool", true)
+				attrs.Set("string", "true")
+
+				value, ok = GetAttributeBool(attrs, "bool")
+
+				Expect(ok).To(BeTrue())
+				Expect(value).To(BeTrue())
+
+				_, ok = GetAttributeBool(attrs, "string")
+
+				Expect(ok).To(BeFalse())
+
+				_, ok = GetAttributeBool(attrs, "missing")
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		g.Describe("calling GetAttributeInt64", func() {
+			g.It("should return the value only if it is an integer", func() {
+				var ok bool
+				var value int64
+
+				attrs.Set("float", 1.5)
+				attrs.Set("int", 1)
+				attrs.Set("json", float64(2))
+				attrs.Set("largeFloat", float64(math.MaxInt64))
+				attrs.Set("largeUint64", uint64(math.MaxInt64)+1)
+				attrs.Set("string", "3")
+
+				value, ok = GetAttributeInt64(attrs, "int")
+
+				Expect(ok).To(BeTrue())
+				Expect(value).To(BeEquivalentTo(1))
+
+				value, ok = GetAttributeInt64(attrs, "json")
+
+				Expect(ok).To(BeTrue())
+				Expect(value).To(BeEquivalentTo(2))
+
+				_, ok = GetAttributeInt64(attrs, "float")
+
+				Expect(ok).To(BeFalse())
+
+				_, ok = GetAttributeInt64(attrs, "string")
+
+				Expect(ok).To(BeFalse())
+
+				_, ok = GetAttributeInt64(attrs, "largeFloat")
+
+				Expect(ok).To(BeFalse())
+
+				_, ok = GetAttributeInt64(attrs, "largeUint64")
+
+				Expect(ok).To(BeFalse())
+			})
+
+			g.It("should accept every integer type", func() {
+				var values = []interface{}{int(-1), int8(-1), int16(-1), int32(-1), int64(-1), uint(1), uint8(1),
+					uint16(1), uint32(1), uint64(1)}
+
+				for _, raw := range values {
+					var ok bool
+					var value int64
+
+					attrs.Set("value", raw)
+
+					value, ok = GetAttributeInt64(attrs, "value")
+
+					Expect(ok).To(BeTrue(), "%T", raw)
+					Expect(value).To(Or(BeEquivalentTo(-1), BeEquivalentTo(1)), "%T", raw)
+				}
+			})
+		})
+
+		g.Describe("calling GetAttributeString", func() {
+			g.It("should return the value only if it is a string", func() {
+				var ok bool
+				var value string
+
+				attrs.Set("int", 1)
+				attrs.Set("string", "value")
+
+				value, ok = GetAttributeString(attrs, "string")
+
+				Expect(ok).To(BeTrue())
+				Expect(value).To(Equal("value"))
+
+				_, ok = GetAttributeString(attrs, "int")
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		g.Describe("calling GetAttributeTime", func() {
+			g.It("should return the value only if it is a time", func() {
+				var now = time.Now()
+				var ok bool
+				var value time.Time
+
+				attrs.Set("invalid", "now")
+				attrs.Set("string", now.Format(time.RFC3339Nano))
+				attrs.Set("time", now)
+
+				value, ok = GetAttributeTime(attrs, "time")
+
+				Expect(ok).To(BeTrue())
+				Expect(value).To(Equal(now))
+
+				value, ok = GetAttributeTime(attrs, "string")
+
+				Expect(ok).To(BeTrue())
+				Expect(value.Equal(now)).To(BeTrue())
+
+				_, ok = GetAttributeTime(attrs, "invalid")
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+})
+
+// Attributes sidecar tests
+
+var _ = g.Describe("AttributesSidecarPath", func() {
+	g.It("should append the sidecar extension", func() {
+		Expect(AttributesSidecarPath("dir/file.txt")).To(Equal("dir/file.txt" + AttributesSidecarExtension))
+	})
+})
+
+var _ = g.Describe("WriteAttributesSidecar", func() {
+	g.It("should write Attributes that can be read by ReadAttributesSidecar", func() {
+		var buffer bytes.Buffer
+		var contentType string
+		var digest string
+		var modTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		var read = newExtendedTestFile(nil)
+		var size int64
+		var value time.Time
+		var written = newExtendedTestFile(nil)
+
+		written.Attributes().Set(AttributeKeyContentType, "text/plain")
+		written.Attributes().Set(HashAlgorithmMD5.AttributeKey(), "digest")
+		written.Attributes().Set("size", 10)
+		written.Attributes().Set("time", modTime)
+
+		Expect(WriteAttributesSidecar(&buffer, written)).To(BeNil())
+		Expect(ReadAttributesSidecar(&buffer, read)).To(BeNil())
+
+		Expect(read.Attributes().Keys()).To(Equal(written.Attributes().Keys()))
+
+		contentType, _ = GetAttributeString(read.Attributes(), AttributeKeyContentType)
+		digest, _ = GetAttributeString(read.Attributes(), HashAlgorithmMD5.AttributeKey())
+		size, _ = GetAttributeInt64(read.Attributes(), "size")
+		value, _ = GetAttributeTime(read.Attributes(), "time")
+
+		Expect(contentType).To(Equal("text/plain"))
+		Expect(digest).To(Equal("digest"))
+		Expect(size).To(BeEquivalentTo(10))
+		Expect(value.Equal(modTime)).To(BeTrue())
+	})
+
+	g.It("should return an error if the Attributes can't be marshalled", func() {
+		var f = newExtendedTestFile(nil)
+
+		f.Attributes().Set("func", func() {})
+
+		Expect(WriteAttributesSidecar(&bytes.Buffer{}, f)).NotTo(BeNil())
+	})
+})
+
+var _ = g.Describe("ReadAttributesSidecar", func() {
+	g.It("should return an error if the sidecar is invalid", func() {
+		var f = newExtendedTestFile(nil)
+
+		Expect(ReadAttributesSidecar(strings.NewReader("["), f)).NotTo(BeNil())
+		Expect(ReadAttributesSidecar(&errorReader{err: errors.New("read")}, f)).NotTo(BeNil())
+		Expect(f.Attributes().Keys()).To(BeEmpty())
+	})
+})
+
+//
+// Private types
+//
+
+// io.Reader implementation that always fails.
+type errorReader struct {
+	err error
+}
+
+func (reader *errorReader) Read(p []byte) (int, error) {
+	return 0, reader.err
+}
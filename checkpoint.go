@@ -0,0 +1,144 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+//
+// Public types
+//
+
+// Checkpoint records how far a Source has progressed through its root path, so that it can resume where it left off
+// rather than starting over.
+type Checkpoint struct {
+	// Base is the path that the paths of the Files produced by the Source are relative to.
+	Base string `json:"base"`
+
+	// Dirs contains the directories that have yet to be listed.
+	Dirs []string `json:"dirs"`
+
+	// Files contains the files that have been listed but not yet produced.
+	Files []string `json:"files"`
+
+	// LastPath is the path of the last File that was produced.
+	LastPath string `json:"lastPath"`
+
+	// Recurse and Root are used to discard Checkpoints created for a different SourceConfig.  If SourceConfig.Root is a
+	// template, Root holds its expansion, so a Checkpoint is also discarded if the root path expands differently.
+	Recurse bool   `json:"recurse"`
+	Root    string `json:"root"`
+}
+
+// CheckpointStore persists Checkpoints by Source ID.
+type CheckpointStore interface {
+	// Clear removes the Checkpoint for a Source.  It is not an error if no Checkpoint exists.
+	Clear(sourceID string) error
+
+	// Load retrieves the Checkpoint for a Source, returning nil if no Checkpoint exists.
+	Load(sourceID string) (*Checkpoint, error)
+
+	Save(sourceID string, checkpoint *Checkpoint) error
+}
+
+//
+// Public constants
+//
+
+// DefaultCheckpointInterval is the number of Files a Source produces between Checkpoints if
+// SourceConfig.CheckpointInterval is not set.
+const DefaultCheckpointInterval = 1000
+
+//
+// Public functions
+//
+
+// NewFileCheckpointStore creates a CheckpointStore that saves each Checkpoint as a JSON file within a directory.  The
+// directory is created if it doesn't exist.
+func NewFileCheckpointStore(dir string) (CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &fileCheckpointStore{
+		dir: dir,
+	}, nil
+}
+
+//
+// Private types
+//
+
+// CheckpointStore implementation that uses one JSON file per Source.
+type fileCheckpointStore struct {
+	dir string
+}
+
+func (store *fileCheckpointStore) Clear(sourceID string) error {
+	if err := os.Remove(store.path(sourceID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (store *fileCheckpointStore) Load(sourceID string) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	var contents []byte
+	var err error
+
+	if contents, err = ioutil.ReadFile(store.path(sourceID)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if err = json.Unmarshal(contents, &checkpoint); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+func (store *fileCheckpointStore) Save(sourceID string, checkpoint *Checkpoint) error {
+	var contents []byte
+	var err error
+	var temp *os.File
+
+	if contents, err = json.Marshal(checkpoint); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a previous Checkpoint isn't lost if the process dies while saving.
+
+	if temp, err = ioutil.TempFile(store.dir, sourceID+".*.tmp"); err != nil {
+		return err
+	}
+
+	if _, err = temp.Write(contents); err == nil {
+		err = temp.Sync()
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), store.path(sourceID))
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+
+	return err
+}
+
+// Source IDs are validated, so they're always safe to use as filenames.
+func (store *fileCheckpointStore) path(sourceID string) string {
+	return filepath.Join(store.dir, sourceID+".json")
+}
@@ -0,0 +1,353 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// File CheckpointStore tests
+
+var _ = g.Describe("NewFileCheckpointStore", func() {
+	var dir string
+
+	g.BeforeEach(func() {
+		var err error
+
+		dir, err = ioutil.TempDir("", "pipewerx")
+
+		Expect(err).To(BeNil())
+	})
+
+	g.AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(BeNil())
+	})
+
+	g.Describe("calling NewFileCheckpointStore", func() {
+		g.Context("with a directory that cannot be created", func() {
+			g.It("should return an error", func() {
+				var err error
+				var store CheckpointStore
+
+				Expect(ioutil.WriteFile(filepath.Join(dir, "file"), []byte{}, 0600)).To(BeNil())
+
+				store, err = NewFileCheckpointStore(filepath.Join(dir, "file", "checkpoints"))
+
+				Expect(err).NotTo(BeNil())
+				Expect(store).To(BeNil())
+			})
+		})
+	})
+
+	g.Describe("given a new instance", func() {
+		var store CheckpointStore
+
+		g.BeforeEach(func() {
+			var err error
+
+			store, err = NewFileCheckpointStore(filepath.Join(dir, "checkpoints"))
+
+			Expect(err).To(BeNil())
+		})
+
+		g.Describe("calling Load", func() {
+			g.Context("for a Source without a Checkpoint", func() {
+				g.It("should return nil", func() {
+					Expect(store.Load("source")).To(BeNil())
+				})
+			})
+
+			g.Context("for a Source with an invalid Checkpoint", func() {
+				g.It("should return an error", func() {
+					var err error
+
+					Expect(ioutil.WriteFile(filepath.Join(dir, "checkpoints", "source.json"), []byte("{"),
+						0600)).To(BeNil())
+
+					_, err = store.Load("source")
+
+					Expect(err).NotTo(BeNil())
+				})
+			})
+		})
+
+		g.Describe("calling Save", func() {
+			g.It("should save a Checkpoint that can be loaded and cleared", func() {
+				var checkpoint = &Checkpoint{
+					Base:     "/root",
+					Dirs:     []string{"/root/dir"},
+					Files:    []string{"/root/file1", "/root/file2"},
+					LastPath: "/root/file3",
+					Recurse:  true,
+					Root:     "/root",
+				}
+				var files []os.FileInfo
+
+				Expect(store.Save("source", checkpoint)).To(BeNil())
+				Expect(store.Load("source")).To(Equal(checkpoint))
+
+				// The temporary file should have been renamed.
+
+				files, _ = ioutil.ReadDir(filepath.Join(dir, "checkpoints"))
+
+				Expect(files).To(HaveLen(1))
+
+				Expect(store.Clear("source")).To(BeNil())
+				Expect(store.Load("source")).To(BeNil())
+				Expect(store.Clear("source")).To(BeNil())
+			})
+		})
+	})
+})
+
+// Source Checkpoint tests
+
+var _ = g.Describe("Source", func() {
+	g.Describe("given a new instance that uses a CheckpointStore", func() {
+		var config SourceConfig
+		var fs *memFilesystem
+		var src Source
+		var store *memCheckpointStore
+
+		g.BeforeEach(func() {
+			store = &memCheckpointStore{
+				checkpoints: make(map[string]*Checkpoint),
+			}
+			config = SourceConfig{
+				CheckpointInterval: 1,
+				Checkpoints:        store,
+				ID:                 "source",
+				Recurse:            true,
+			}
+			fs = &memFilesystem{
+				root: &memFilesystemNode{
+					children: map[string]*memFilesystemNode{
+						"dir1": {
+							children: map[string]*memFilesystemNode{
+								"file1": {},
+								"file2": {},
+							},
+						},
+						"dir2": {
+							children: map[string]*memFilesystemNode{
+								"file3": {},
+								"file4": {},
+							},
+						},
+						"file5": {},
+						"file6": {},
+					},
+				},
+			}
+		})
+
+		g.JustBeforeEach(func() {
+			var err error
+
+			src, err = NewSource(config, fs)
+
+			Expect(err).To(BeNil())
+		})
+
+		g.Describe("calling Files without cancelling", func() {
+			g.It("should produce every File and clear the Checkpoint", func() {
+				Expect(resultFilePaths(collectSourceResults(src))).To(ConsistOf("dir1/file1", "dir1/file2",
+					"dir2/file3", "dir2/file4", "file5", "file6"))
+				Expect(store.Load("source")).To(BeNil())
+				Expect(store.saves).To(Equal(6))
+			})
+		})
+
+		g.Describe("calling Files and cancelling", func() {
+			g.It("should resume where it left off when Files is called again", func() {
+				var cancel CancelFunc
+				var first []Result
+				var in <-chan Result
+				var second []Result
+
+				in, cancel = src.Files(NewContext(ContextConfig{}))
+
+				first = append(first, <-in, <-in)
+
+				cancel(nil)
+
+				for res := range in {
+					first = append(first, res)
+				}
+
+				Expect(first).To(HaveLen(len(resultFilePaths(first))))
+				Expect(store.Load("source")).NotTo(BeNil())
+
+				second = collectSourceResults(src)
+
+				Expect(append(resultFilePaths(first), resultFilePaths(second)...)).To(ConsistOf(
+					"dir1/file1", "dir1/file2", "dir2/file3", "dir2/file4", "file5", "file6"))
+				Expect(store.Load("source")).To(BeNil())
+			})
+		})
+
+		g.Context("with a Checkpoint for a file that no longer exists", func() {
+			g.BeforeEach(func() {
+				store.checkpoints["source"] = &Checkpoint{
+					Dirs:     []string{"/dir2"},
+					Files:    []string{"/missing", "/file5"},
+					LastPath: "/file6",
+					Recurse:  true,
+				}
+			})
+
+			g.It("should skip the file", func() {
+				Expect(resultFilePaths(collectSourceResults(src))).To(ConsistOf("dir2/file3", "dir2/file4",
+					"file5"))
+			})
+		})
+
+		g.Context("with a directory that can't be listed the first time and ErrorPolicyFailFast", func() {
+			g.BeforeEach(func() {
+				config.ErrorPolicy = ErrorPolicyFailFast
+			})
+
+			g.It("should list the directory again when the Source resumes", func() {
+				var err error
+				var first []Result
+				var second []Result
+
+				src, err = NewSource(config, &flakyMemFilesystem{
+					failPath:      "/dir2",
+					memFilesystem: fs,
+				})
+
+				Expect(err).To(BeNil())
+
+				first = collectSourceResults(src)
+
+				Expect(first).NotTo(BeEmpty())
+				Expect(first[len(first)-1].Error()).To(beAPathError("source", OperationList, "/dir2",
+					ErrorCategoryFatal))
+				Expect(store.Load("source")).NotTo(BeNil())
+				Expect(store.checkpoints["source"].Dirs).To(ContainElement("/dir2"))
+
+				second = collectSourceResults(src)
+
+				Expect(append(resultFilePaths(first), resultFilePaths(second)...)).To(ConsistOf(
+					"dir1/file1", "dir1/file2", "dir2/file3", "dir2/file4", "file5", "file6"))
+				Expect(store.Load("source")).To(BeNil())
+			})
+		})
+
+		g.Context("with a Checkpoint created for a different root", func() {
+			g.BeforeEach(func() {
+				store.checkpoints["source"] = &Checkpoint{
+					Files: []string{"/file5"},
+					Root:  "/dir1",
+				}
+			})
+
+			g.It("should ignore the Checkpoint", func() {
+				Expect(collectSourceResults(src)).To(HaveLen(6))
+			})
+		})
+
+		g.Context("with a CheckpointStore that returns an error when loading", func() {
+			g.BeforeEach(func() {
+				store.loadError = errors.New("load")
+			})
+
+			g.It("should return an error", func() {
+				var results = collectSourceResults(src)
+
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Error()).To(MatchError("source: checkpoint: load"))
+			})
+		})
+
+		g.Context("with a CheckpointStore that returns an error when saving", func() {
+			g.BeforeEach(func() {
+				config.CheckpointInterval = 4
+				config.ErrorPolicy = ErrorPolicyFailFast
+				store.saveError = errors.New("save")
+			})
+
+			g.It("should return an error", func() {
+				var results = collectSourceResults(src)
+
+				Expect(results).To(HaveLen(5))
+				Expect(results[4].Error()).To(beAPathError("source", OperationCheckpoint, "", ErrorCategoryFatal))
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// In-memory Filesystem implementation that fails to list a directory the first time it is listed.
+type flakyMemFilesystem struct {
+	*memFilesystem
+
+	failPath string
+	failed   bool
+}
+
+func (fs *flakyMemFilesystem) ListFiles(path string) ([]os.FileInfo, error) {
+	if path == fs.failPath && !fs.failed {
+		fs.failed = true
+
+		return nil, errors.New("listFiles")
+	}
+
+	return fs.memFilesystem.ListFiles(path)
+}
+
+// In-memory CheckpointStore implementation.
+type memCheckpointStore struct {
+	checkpoints map[string]*Checkpoint
+	loadError   error
+	mutex       sync.Mutex
+	saveError   error
+	saves       int
+}
+
+func (store *memCheckpointStore) Clear(sourceID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.checkpoints, sourceID)
+
+	return nil
+}
+
+func (store *memCheckpointStore) Load(sourceID string) (*Checkpoint, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.loadError != nil {
+		return nil, store.loadError
+	}
+
+	return store.checkpoints[sourceID], nil
+}
+
+func (store *memCheckpointStore) Save(sourceID string, checkpoint *Checkpoint) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.saveError != nil {
+		return store.saveError
+	}
+
+	store.checkpoints[sourceID] = checkpoint
+	store.saves++
+
+	return nil
+}
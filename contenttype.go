@@ -0,0 +1,225 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+//
+// Public types
+//
+
+type ContentTypeEvaluatorConfig struct {
+	// Exclude determines whether Files with matching content types are dropped.  If Exclude is false, only Files with
+	// matching content types are kept.
+	Exclude bool
+
+	// Types contains the media types to match (e.g., "application/pdf").  Parameters such as charset are ignored when
+	// matching, and a subtype of "*" matches any subtype (e.g., "image/*").
+	Types []string
+}
+
+//
+// Public constants
+//
+
+// AttributeKeyContentType is the key of the File attribute that holds the content type detected by
+// DetectContentType().
+const AttributeKeyContentType = "content.type"
+
+//
+// Public functions
+//
+
+// DetectContentType determines the content type of a File by inspecting the beginning of its contents rather than
+// trusting its extension.  If the content type has already been attached to the File, it is returned as-is; otherwise
+// the File is read and the content type is attached to the File before being returned.  The returned content type
+// always has a value, and is "application/octet-stream" if nothing more specific can be determined.
+func DetectContentType(file File) (string, error) {
+	var amount int
+	var contentType string
+	var data = make([]byte, contentTypeSniffLength)
+	var err error
+	var reader io.ReadCloser
+
+	if value, ok := file.Attributes().Get(AttributeKeyContentType); ok {
+		if contentType, ok = value.(string); ok {
+			return contentType, nil
+		}
+	}
+
+	if reader, err = file.Reader(); err != nil {
+		return "", err
+	}
+
+	amount, err = io.ReadFull(reader, data)
+
+	_ = reader.Close()
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	contentType = sniffContentType(data[:amount])
+
+	file.Attributes().Set(AttributeKeyContentType, contentType)
+
+	return contentType, nil
+}
+
+// NewContentTypeEvaluator creates a FileEvaluator that keeps or drops Files based on the content type detected by
+// DetectContentType().
+func NewContentTypeEvaluator(config ContentTypeEvaluatorConfig) (FileEvaluator, error) {
+	var evaluator = &contentTypeEvaluator{
+		exclude: config.Exclude,
+	}
+
+	for _, contentType := range config.Types {
+		var mediaType, _, err = mime.ParseMediaType(contentType)
+
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return nil, fmt.Errorf("invalid content type '%s'", contentType)
+		}
+
+		evaluator.types = append(evaluator.types, mediaType)
+	}
+
+	return evaluator, nil
+}
+
+//
+// Private types
+//
+
+// FileEvaluator implementation that compares the detected content type of Files against a list of media types
+type contentTypeEvaluator struct {
+	exclude bool
+	types   []string
+}
+
+func (evaluator *contentTypeEvaluator) Destroy() error {
+	return nil
+}
+
+func (evaluator *contentTypeEvaluator) ShouldKeep(file File) (bool, error) {
+	var contentType string
+	var err error
+	var mediaType string
+
+	if contentType, err = DetectContentType(file); err != nil {
+		return false, err
+	}
+
+	// The detected content type is always valid, so the only thing that needs to be done is to strip the parameters.
+
+	mediaType, _, _ = mime.ParseMediaType(contentType)
+
+	for _, pattern := range evaluator.types {
+		if pattern == mediaType || (strings.HasSuffix(pattern, "/*") &&
+			strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))) {
+			return !evaluator.exclude, nil
+		}
+	}
+
+	return evaluator.exclude, nil
+}
+
+// Describes a content type that is identified by a sequence of bytes at a given offset.
+type magicNumber struct {
+	contentType string
+	magic       []byte
+	offset      int
+}
+
+//
+// Private constants
+//
+
+// Large enough to find the names of the parts that identify Office Open XML documents in most cases.
+const contentTypeSniffLength = 4096
+
+// The characters that can appear in an OpenDocument content type.
+const openDocumentContentTypeChars = "abcdefghijklmnopqrstuvwxyz0123456789+-./"
+
+//
+// Private variables
+//
+
+// Content types that aren't detected by http.DetectContentType().
+var magicNumbers = []magicNumber{
+	{contentType: "application/x-7z-compressed", magic: []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}},
+	{contentType: "application/x-bzip2", magic: []byte("BZh")},
+	{contentType: "application/x-ole-storage", magic: []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}},
+	{contentType: "application/x-tar", magic: []byte("ustar"), offset: 257},
+	{contentType: "application/x-xz", magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{contentType: "text/plain; charset=utf-32be", magic: []byte{0x00, 0x00, 0xfe, 0xff}},
+	{contentType: "text/plain; charset=utf-32le", magic: []byte{0xff, 0xfe, 0x00, 0x00}},
+}
+
+// Office Open XML documents are ZIP archives that are identified by the names of the parts they contain.
+var officeOpenXMLParts = []struct {
+	contentType string
+	part        []byte
+}{
+	{contentType: "application/vnd.openxmlformats-officedocument.presentationml.presentation", part: []byte("ppt/")},
+	{contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", part: []byte("xl/")},
+	{contentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", part: []byte("word/")},
+}
+
+// OpenDocument files are ZIP archives that start with a "mimetype" part containing the content type.
+var openDocumentMarker = []byte("mimetypeapplication/vnd.oasis.opendocument.")
+
+//
+// Private functions
+//
+
+func sniffContentType(data []byte) string {
+	var contentType string
+
+	for _, number := range magicNumbers {
+		if len(data) >= number.offset+len(number.magic) &&
+			bytes.Equal(data[number.offset:number.offset+len(number.magic)], number.magic) {
+			return number.contentType
+		}
+	}
+
+	contentType = http.DetectContentType(data)
+
+	if contentType == "application/zip" {
+		contentType = sniffZIPContentType(data)
+	}
+
+	return contentType
+}
+
+func sniffZIPContentType(data []byte) string {
+	// The "mimetype" part is always the first part of an OpenDocument file, and since it's stored rather than
+	// compressed its contents directly follow the 30 byte local file header and part name.  The sizes in the local file
+	// header can't be relied upon (they may be recorded in a data descriptor instead), so the content type ends at the
+	// first character that can't be part of it.
+
+	if len(data) > 30 && bytes.HasPrefix(data[30:], openDocumentMarker) {
+		var start = 30 + len("mimetype")
+		var end = start
+
+		for end < len(data) && strings.IndexByte(openDocumentContentTypeChars, data[end]) != -1 {
+			end++
+		}
+
+		return string(data[start:end])
+	}
+
+	if bytes.Contains(data, []byte("[Content_Types].xml")) {
+		for _, part := range officeOpenXMLParts {
+			if bytes.Contains(data, part.part) {
+				return part.contentType
+			}
+		}
+	}
+
+	return "application/zip"
+}
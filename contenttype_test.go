@@ -0,0 +1,215 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// DetectContentType tests
+
+var _ = g.Describe("DetectContentType", func() {
+	g.Describe("calling DetectContentType", func() {
+		g.It("should detect the content type regardless of extension", func() {
+			for contents, contentType := range map[string]string{
+				"":                                 "text/plain; charset=utf-8",
+				"plain text":                       "text/plain; charset=utf-8",
+				"\xff\xfe\x00\x00t\x00\x00\x00":    "text/plain; charset=utf-32le",
+				"%PDF-1.4\n":                       "application/pdf",
+				"\x89PNG\x0d\x0a\x1a\x0a":          "image/png",
+				"7z\xbc\xaf\x27\x1c\x00\x04":       "application/x-7z-compressed",
+				"\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1": "application/x-ole-storage",
+				"PK\x03\x04":                       "application/zip",
+				newTestTar():                       "application/x-tar",
+				newTestZIP(false, "[Content_Types].xml", "_rels/.rels", "word/document.xml"): "application/" +
+					"vnd.openxmlformats-officedocument.wordprocessingml.document",
+				newTestZIP(false, "[Content_Types].xml", "xl/workbook.xml"): "application/" +
+					"vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				newTestZIP(true, "mimetype", "content.xml"): "application/vnd.oasis.opendocument.text",
+				newTestZIP(false, "file.txt"):               "application/zip",
+			} {
+				var f = newContentTypeTestFile(contents)
+
+				Expect(DetectContentType(f)).To(Equal(contentType))
+				Expect(attributeValue(f, AttributeKeyContentType)).To(Equal(contentType))
+			}
+		})
+
+		g.Context("for a file that already has a content type attached", func() {
+			g.It("should return the attached content type without reading the file", func() {
+				var f = newContentTypeTestFile("plain text")
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				f.Attributes().Set(AttributeKeyContentType, "image/png")
+
+				Expect(DetectContentType(f)).To(Equal("image/png"))
+			})
+		})
+
+		g.Context("for a file that cannot be read", func() {
+			g.It("should return an error", func() {
+				var err error
+				var f = newContentTypeTestFile("plain text")
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				_, err = DetectContentType(f)
+
+				Expect(err).To(beAPathError("", OperationRead, "name.pdf", ErrorCategoryFatal))
+			})
+		})
+	})
+})
+
+// Content type FileEvaluator tests
+
+var _ = g.Describe("NewContentTypeEvaluator", func() {
+	g.Describe("calling NewContentTypeEvaluator", func() {
+		g.Context("with an invalid content type", func() {
+			g.It("should return an error", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewContentTypeEvaluator(ContentTypeEvaluatorConfig{
+					Types: []string{"image/png", "image"},
+				})
+
+				Expect(err).To(MatchError("invalid content type 'image'"))
+				Expect(evaluator).To(BeNil())
+			})
+		})
+
+		g.Context("with a list of content types to keep", func() {
+			g.It("should only keep files with matching content types", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewContentTypeEvaluator(ContentTypeEvaluatorConfig{
+					Types: []string{"image/*", "text/plain"},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(evaluator.ShouldKeep(newContentTypeTestFile("\x89PNG\x0d\x0a\x1a\x0a"))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newContentTypeTestFile("plain text"))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newContentTypeTestFile("%PDF-1.4\n"))).To(BeFalse())
+				Expect(evaluator.Destroy()).To(BeNil())
+			})
+		})
+
+		g.Context("with a list of content types to exclude", func() {
+			g.It("should drop files with matching content types", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewContentTypeEvaluator(ContentTypeEvaluatorConfig{
+					Exclude: true,
+					Types:   []string{"application/pdf"},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(evaluator.ShouldKeep(newContentTypeTestFile("%PDF-1.4\n"))).To(BeFalse())
+				Expect(evaluator.ShouldKeep(newContentTypeTestFile("plain text"))).To(BeTrue())
+			})
+		})
+
+		g.Context("for a file that cannot be read", func() {
+			g.It("should return an error", func() {
+				var err error
+				var evaluator FileEvaluator
+				var f = newContentTypeTestFile("plain text")
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				evaluator, err = NewContentTypeEvaluator(ContentTypeEvaluatorConfig{})
+
+				Expect(err).To(BeNil())
+
+				_, err = evaluator.ShouldKeep(f)
+
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	})
+})
+
+//
+// Private functions
+//
+
+// Files are given a misleading extension to ensure it isn't used to determine the content type.
+func newContentTypeTestFile(contents string) *file {
+	return &file{
+		fileInfo: &nilFileInfo{
+			name: "name.pdf",
+			size: int64(len(contents)),
+		},
+		fs: &memFilesystem{
+			root: &memFilesystemNode{
+				children: map[string]*memFilesystemNode{
+					"name.pdf": {
+						contents: contents,
+					},
+				},
+			},
+		},
+		path: newFilePath(nil, "name.pdf", "/"),
+	}
+}
+
+func newTestTar() string {
+	var buffer bytes.Buffer
+	var writer = tar.NewWriter(&buffer)
+
+	Expect(writer.WriteHeader(&tar.Header{
+		Format: tar.FormatUSTAR,
+		Mode:   0644,
+		Name:   "file.txt",
+		Size:   4,
+	})).To(BeNil())
+	Expect(writer.Write([]byte("text"))).To(Equal(4))
+	Expect(writer.Close()).To(BeNil())
+
+	return buffer.String()
+}
+
+// Creates a ZIP archive containing the given parts.  If openDocument is true, the first part is stored rather than
+// compressed and contains an OpenDocument content type, as required by the OpenDocument specification.
+func newTestZIP(openDocument bool, names ...string) string {
+	var buffer bytes.Buffer
+	var writer = zip.NewWriter(&buffer)
+
+	for i, name := range names {
+		var contents = "contents"
+		var err error
+		var header = &zip.FileHeader{
+			Method: zip.Deflate,
+			Name:   name,
+		}
+		var part interface {
+			Write(p []byte) (int, error)
+		}
+
+		if openDocument && i == 0 {
+			contents = "application/vnd.oasis.opendocument.text"
+			header.Method = zip.Store
+		}
+
+		part, err = writer.CreateHeader(header)
+
+		Expect(err).To(BeNil())
+		Expect(part.Write([]byte(contents))).To(Equal(len(contents)))
+	}
+
+	Expect(writer.Close()).To(BeNil())
+
+	return buffer.String()
+}
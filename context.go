@@ -1,17 +1,21 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	gocontext "context"
 	"io"
 	"os"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/api/trace"
 )
 
 //
 // Public types
 //
 
+// Context is passed to every Source and Filter in a pipeline.  Only Contexts created using NewContext(), or derived
+// from one using WithTraceContext(), are traced.
 type Context interface {
 	Copy() Context
 
@@ -23,7 +27,16 @@ type Context interface {
 }
 
 type ContextConfig struct {
-	Level   zerolog.Level
+	Level zerolog.Level
+
+	// TraceContext is used as the parent of any tracing spans.  context.Background() is used if no context.Context is
+	// provided.
+	TraceContext gocontext.Context
+
+	// Tracer is used to create tracing spans for Sources, Filters, and file reads.  Tracing is disabled if no Tracer is
+	// provided.
+	Tracer trace.Tracer
+
 	UseJSON bool
 	Writer  io.Writer
 }
@@ -49,14 +62,39 @@ func NewContext(config ContextConfig) Context {
 		}
 	}
 
+	if config.TraceContext == nil {
+		config.TraceContext = gocontext.Background()
+	}
+
+	if config.Tracer == nil {
+		config.Tracer = trace.NoopTracer{}
+	}
+
 	logger = zerolog.New(writer).With().
 		Timestamp().
 		Logger().
 		Level(config.Level)
 
 	return &context{
-		logger: &logger,
-		vars:   make(map[string]interface{}),
+		logger:       &logger,
+		traceContext: config.TraceContext,
+		tracer:       config.Tracer,
+		vars:         make(map[string]interface{}),
+	}
+}
+
+// WithTraceContext returns a Context that uses the given context.Context as the parent of any tracing spans, but
+// otherwise shares everything, including its variables, with the given Context.  This can be used to make a pipeline
+// run appear as part of the caller's trace.
+func WithTraceContext(context Context, traceContext gocontext.Context) Context {
+	if traced, ok := context.(*tracedContext); ok {
+		context = traced.Context
+	}
+
+	return &tracedContext{
+		Context:      context,
+		traceContext: traceContext,
+		tracer:       tracerOf(context),
 	}
 }
 
@@ -66,8 +104,10 @@ func NewContext(config ContextConfig) Context {
 
 // Context implementation
 type context struct {
-	logger *zerolog.Logger
-	vars   map[string]interface{}
+	logger       *zerolog.Logger
+	traceContext gocontext.Context
+	tracer       trace.Tracer
+	vars         map[string]interface{}
 }
 
 func (ctx *context) Copy() Context {
@@ -78,8 +118,10 @@ func (ctx *context) Copy() Context {
 	}
 
 	return &context{
-		logger: ctx.logger,
-		vars:   newVars,
+		logger:       ctx.logger,
+		traceContext: ctx.traceContext,
+		tracer:       ctx.tracer,
+		vars:         newVars,
 	}
 }
 
@@ -96,3 +138,61 @@ func (ctx *context) SetLogLevel(level zerolog.Level) {
 func (ctx *context) Vars() map[string]interface{} {
 	return ctx.vars
 }
+
+func (ctx *context) tracing() (gocontext.Context, trace.Tracer) {
+	return ctx.traceContext, ctx.tracer
+}
+
+// Context implementation that uses a different parent for tracing spans than the Context it wraps.  Everything else is
+// delegated to the wrapped Context, so changes to its variables or log level are seen by both.
+type tracedContext struct {
+	Context
+
+	traceContext gocontext.Context
+	tracer       trace.Tracer
+}
+
+func (ctx *tracedContext) Copy() Context {
+	return &tracedContext{
+		Context:      ctx.Context.Copy(),
+		traceContext: ctx.traceContext,
+		tracer:       ctx.tracer,
+	}
+}
+
+func (ctx *tracedContext) tracing() (gocontext.Context, trace.Tracer) {
+	return ctx.traceContext, ctx.tracer
+}
+
+// Implemented by the Contexts that carry tracing information.
+type tracingContext interface {
+	tracing() (gocontext.Context, trace.Tracer)
+}
+
+//
+// Private functions
+//
+
+// Returns the context.Context that is used as the parent of any tracing spans created using a Context.  Contexts that
+// don't carry tracing information use context.Background().
+func traceContextOf(context Context) gocontext.Context {
+	if traced, ok := context.(tracingContext); ok {
+		var traceContext, _ = traced.tracing()
+
+		return traceContext
+	}
+
+	return gocontext.Background()
+}
+
+// Returns the Tracer that is used to create tracing spans using a Context.  Contexts that don't carry tracing
+// information use a Tracer that does nothing.
+func tracerOf(context Context) trace.Tracer {
+	if traced, ok := context.(tracingContext); ok {
+		var _, tracer = traced.tracing()
+
+		return tracer
+	}
+
+	return trace.NoopTracer{}
+}
@@ -2,11 +2,14 @@ package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
 	"bytes"
+	gocontext "context"
 	"strings"
 
 	g "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/api/trace/testtrace"
 )
 
 //
@@ -44,6 +47,18 @@ var _ = g.Describe("Context", func() {
 				})
 			})
 
+			g.Describe("calling traceContextOf", func() {
+				g.It("should return the background context", func() {
+					Expect(traceContextOf(context)).To(Equal(gocontext.Background()))
+				})
+			})
+
+			g.Describe("calling tracerOf", func() {
+				g.It("should return a Tracer that does nothing", func() {
+					Expect(tracerOf(context)).To(Equal(trace.NoopTracer{}))
+				})
+			})
+
 			g.Describe("calling Vars", func() {
 				g.It("should return the expected variables", func() {
 					Expect(context.Vars()).To(HaveLen(1))
@@ -51,6 +66,83 @@ var _ = g.Describe("Context", func() {
 					Expect(context.Vars()["key"]).To(Equal("value"))
 				})
 			})
+
+			g.Describe("calling WithTraceContext", func() {
+				g.It("should return a Context that uses the given context.Context and shares everything else", func() {
+					var tracedContext Context
+					var traceContext = gocontext.WithValue(gocontext.Background(), testContextKey{}, "value")
+
+					tracedContext = WithTraceContext(context, traceContext)
+
+					Expect(traceContextOf(tracedContext)).To(Equal(traceContext))
+					Expect(traceContextOf(context)).To(Equal(gocontext.Background()))
+					Expect(tracedContext.Log()).To(BeIdenticalTo(context.Log()))
+
+					tracedContext.Vars()["other"] = "value"
+
+					Expect(context.Vars()).To(HaveKeyWithValue("other", "value"))
+				})
+			})
+
+			g.Describe("calling startSpan", func() {
+				g.It("should return the same Context since tracing is disabled", func() {
+					var spanContext, span = startSpan(context, "span")
+
+					span.End()
+
+					Expect(spanContext).To(BeIdenticalTo(context))
+				})
+			})
+		})
+
+		g.Context("that wasn't created by NewContext", func() {
+			g.JustBeforeEach(func() {
+				context = &externalContext{
+					Context: NewContext(ContextConfig{
+						Tracer: testtrace.NewTracer(),
+					}),
+				}
+			})
+
+			g.Describe("calling tracerOf", func() {
+				g.It("should return a Tracer that does nothing", func() {
+					Expect(tracerOf(context)).To(Equal(trace.NoopTracer{}))
+				})
+			})
+		})
+
+		g.Context("with a Tracer specified", func() {
+			var tracer *testtrace.Tracer
+
+			g.JustBeforeEach(func() {
+				tracer = testtrace.NewTracer()
+
+				context = NewContext(ContextConfig{
+					Tracer: tracer,
+				})
+			})
+
+			g.Describe("calling Copy", func() {
+				g.It("should return a Context that uses the same Tracer", func() {
+					Expect(tracerOf(context.Copy())).To(BeIdenticalTo(tracer))
+				})
+			})
+
+			g.Describe("calling startSpan", func() {
+				g.It("should return a Context that contains the new span and shares everything else", func() {
+					var spanContext, span = startSpan(context, "span")
+
+					span.End()
+
+					Expect(trace.SpanFromContext(traceContextOf(spanContext))).To(BeIdenticalTo(span))
+					Expect(tracerOf(spanContext)).To(BeIdenticalTo(tracer))
+
+					spanContext.Vars()["key"] = "value"
+
+					Expect(context.Vars()).To(HaveKeyWithValue("key", "value"))
+					Expect(tracerOf(spanContext.Copy())).To(BeIdenticalTo(tracer))
+				})
+			})
 		})
 
 		g.Context("with a log level specified", func() {
@@ -102,3 +194,16 @@ var _ = g.Describe("Context", func() {
 		})
 	})
 })
+
+//
+// Private types
+//
+
+// Context implementation that stands in for one implemented outside of pipewerx, so it hides the tracing information of
+// the Context it wraps.
+type externalContext struct {
+	Context
+}
+
+// Key type used to store values in a context.Context.
+type testContextKey struct{}
@@ -0,0 +1,379 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/api/trace"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Public types
+//
+
+// DedupeFilter is a Filter that suppresses Files whose contents are identical to those of another File.  Since the
+// copy that is kept can depend on every File produced by its Sources, a DedupeFilter only produces Files once its
+// Sources have finished producing them.  Error Results are sent downstream as they are encountered.
+type DedupeFilter interface {
+	// Duplicates returns the Files that were suppressed by the most recent call to Files(), grouped by the File that
+	// was kept in their place.
+	Duplicates() []Duplicate
+
+	Filter
+}
+
+type DedupeFilterConfig struct {
+	// Algorithm is used to compute digests for Files with identical sizes.  Files whose sizes are unique are never
+	// read.
+	Algorithm HashAlgorithm
+
+	ErrorPolicy ErrorPolicy
+	ID          string
+	Policy      DedupePolicy
+
+	// PreferredSources contains Source IDs in order of preference and is used by DedupePolicyPreferredSource.
+	PreferredSources []string
+}
+
+// DedupePolicy determines which copy of a File is kept by a DedupeFilter.
+type DedupePolicy int
+
+// Duplicate records the Files that were suppressed by a DedupeFilter in favor of another File.
+type Duplicate struct {
+	Kept       File
+	Suppressed []File
+}
+
+//
+// Public constants
+//
+
+const (
+	// DedupePolicyFirst keeps the first copy that was produced.  This is the default.
+	DedupePolicyFirst DedupePolicy = iota
+
+	// DedupePolicyNewest keeps the copy with the most recent modification time, falling back to the first copy that
+	// was produced.
+	DedupePolicyNewest
+
+	// DedupePolicyPreferredSource keeps the copy produced by the Source that appears first in
+	// DedupeFilterConfig.PreferredSources, falling back to the first copy that was produced.
+	DedupePolicyPreferredSource
+)
+
+//
+// Public functions
+//
+
+func NewDedupeFilter(config DedupeFilterConfig, sources []Source) (DedupeFilter, error) {
+	var err error
+	var merged Source
+	var preferences = make(map[string]int, len(config.PreferredSources))
+
+	if err = validateID(config.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err = newHash(config.Algorithm); err != nil {
+		return nil, err
+	}
+
+	if merged, err = newMergedSource(config.ID, sources); err != nil {
+		return nil, err
+	}
+
+	for i, id := range config.PreferredSources {
+		if _, ok := preferences[id]; !ok {
+			preferences[id] = i
+		}
+	}
+
+	if event.IsAllowedFrom(componentFilter) {
+		event.Send(filterEventCreated(config.ID))
+	}
+
+	return &dedupeFilter{
+		config:      config,
+		input:       merged,
+		preferences: preferences,
+	}, nil
+}
+
+//
+// Private types
+//
+
+// DedupeFilter implementation
+type dedupeFilter struct {
+	config      DedupeFilterConfig
+	duplicates  []Duplicate
+	input       Source
+	mutex       sync.Mutex
+	preferences map[string]int
+}
+
+func (f *dedupeFilter) Duplicates() []Duplicate {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]Duplicate(nil), f.duplicates...)
+}
+
+func (f *dedupeFilter) Files(context Context) (<-chan Result, CancelFunc) {
+	var cancel = make(chan struct{})
+	var cancelHelper *cancellationHelper
+	var out = make(chan Result)
+	var span trace.Span
+
+	context, span = startSpan(context, SpanFilterFiles, traceAttributeFilterID(f.ID()))
+
+	cancelHelper = newCancellationHelper(context.Log(), componentFilter, f.ID(), out, cancel, nil)
+
+	go func() {
+		var errorHelper = newErrorPolicyHelper(context.Log(), f.ID(), f.config.ErrorPolicy)
+		var files []File
+		var handleError func(res Result) bool
+		var in <-chan Result
+		var send func(res Result) bool
+		var sourceCancel CancelFunc
+		var suppressed map[File]bool
+
+		if event.IsAllowedFrom(componentFilter) {
+			event.Send(filterEventStarted(f.ID()))
+		}
+
+		defer func() {
+			if event.IsAllowedFrom(componentFilter) {
+				event.Send(filterEventFinished(f.ID()))
+			}
+
+			span.End()
+
+			cancelHelper.finalize()
+		}()
+
+		send = func(res Result) bool {
+			select {
+			case out <- res:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventResultProduced(f.ID(), res))
+				}
+
+				if res.Error() != nil {
+					span.RecordError(traceContextOf(context), res.Error())
+				}
+
+				return true
+
+			case <-cancel:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventCancelled(f.ID()))
+				}
+
+				return false
+			}
+		}
+
+		// Error Results are handled immediately.  handleError() only returns false if the Filter has been cancelled or
+		// the ErrorPolicy requires processing to stop.
+
+		handleError = func(res Result) bool {
+			var stop bool
+
+			res, stop = errorHelper.handle(res)
+
+			return (res == nil || send(res)) && !stop
+		}
+
+		in, sourceCancel = f.input.Files(context)
+
+	collect:
+		for {
+			select {
+			case res, ok := <-in:
+				if !ok {
+					break collect
+				}
+
+				if res.Error() != nil {
+					if !handleError(res) {
+						sourceCancel(nil)
+
+						return
+					}
+
+					continue
+				}
+
+				files = append(files, res.File())
+
+			case <-cancel:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventCancelled(f.ID()))
+				}
+
+				sourceCancel(nil)
+
+				return
+			}
+		}
+
+		if suppressed = f.findDuplicates(files, cancel, handleError); suppressed == nil {
+			return
+		}
+
+		for _, file := range files {
+			if suppressed[file] {
+				continue
+			}
+
+			if !send(&result{file: file}) {
+				return
+			}
+		}
+
+		if res := errorHelper.finish(); res != nil {
+			send(res)
+		}
+	}()
+
+	return out, cancelHelper.invoker()
+}
+
+func (f *dedupeFilter) ID() string {
+	return f.config.ID
+}
+
+func (f *dedupeFilter) destroy() error {
+	if event.IsAllowedFrom(componentFilter) {
+		event.Send(filterEventDestroyed(f.ID()))
+	}
+
+	return nil
+}
+
+// Determines which Files should be suppressed, recording the resulting Duplicates.  Files that cannot be read are also
+// suppressed, with their errors passed to handleError().  A nil map is returned if the Filter is cancelled or
+// handleError() indicates that processing should stop.
+func (f *dedupeFilter) findDuplicates(files []File, cancel <-chan struct{},
+	handleError func(res Result) bool) map[File]bool {
+	var bySize = make(map[int64][]File)
+	var duplicates []Duplicate
+	var sizes []int64
+	var suppressed = make(map[File]bool)
+
+	// Group by size first, since Files with unique sizes can't have duplicates and don't need to be read.  Sizes are
+	// kept in the order they were first seen so that Duplicates are reported in a predictable order.
+
+	for _, file := range files {
+		if _, ok := bySize[file.Size()]; !ok {
+			sizes = append(sizes, file.Size())
+		}
+
+		bySize[file.Size()] = append(bySize[file.Size()], file)
+	}
+
+	for _, size := range sizes {
+		var byDigest = make(map[string][]File)
+		var digests []string
+
+		if len(bySize[size]) < 2 {
+			continue
+		}
+
+		for _, file := range bySize[size] {
+			var digest string
+			var err error
+
+			// Digesting can mean reading a lot of data, so stop as soon as the Filter is cancelled.
+
+			select {
+			case <-cancel:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventCancelled(f.ID()))
+				}
+
+				return nil
+
+			default:
+			}
+
+			if digest, err = Digest(file, f.config.Algorithm); err != nil {
+				suppressed[file] = true
+
+				if !handleError(&result{
+					err: newPathError(f.ID(), OperationEvaluate, file.Path().String(), err),
+				}) {
+					return nil
+				}
+
+				continue
+			}
+
+			if _, ok := byDigest[digest]; !ok {
+				digests = append(digests, digest)
+			}
+
+			byDigest[digest] = append(byDigest[digest], file)
+		}
+
+		for _, digest := range digests {
+			var duplicate Duplicate
+			var group = byDigest[digest]
+
+			if len(group) < 2 {
+				continue
+			}
+
+			duplicate.Kept = f.keeper(group)
+
+			for _, file := range group {
+				if file != duplicate.Kept {
+					duplicate.Suppressed = append(duplicate.Suppressed, file)
+					suppressed[file] = true
+				}
+			}
+
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+
+	f.mutex.Lock()
+
+	f.duplicates = duplicates
+
+	f.mutex.Unlock()
+
+	return suppressed
+}
+
+// Chooses the File to keep from a group of identical Files, which are in the order they were produced.
+func (f *dedupeFilter) keeper(group []File) File {
+	var kept = group[0]
+
+	for _, file := range group[1:] {
+		switch f.config.Policy {
+		case DedupePolicyNewest:
+			if file.ModTime().After(kept.ModTime()) {
+				kept = file
+			}
+
+		case DedupePolicyPreferredSource:
+			if f.isPreferred(file.SourceID(), kept.SourceID()) {
+				kept = file
+			}
+		}
+	}
+
+	return kept
+}
+
+// Determines whether one Source is preferred over another.  Sources that aren't listed in
+// DedupeFilterConfig.PreferredSources are never preferred.
+func (f *dedupeFilter) isPreferred(sourceID, otherSourceID string) bool {
+	var otherRank, otherOK = f.preferences[otherSourceID]
+	var rank, ok = f.preferences[sourceID]
+
+	return ok && (!otherOK || rank < otherRank)
+}
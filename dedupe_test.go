@@ -0,0 +1,345 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// DedupeFilter tests
+
+var _ = g.Describe("DedupeFilter", func() {
+	g.Describe("given a new instance", func() {
+		var config DedupeFilterConfig
+		var err error
+		var filter DedupeFilter
+		var sink *testEventSink
+		var sources []Source
+
+		g.BeforeEach(func() {
+			var now = time.Now()
+
+			sink = newTestEventSink()
+
+			event.RegisterSink(sink)
+
+			config = DedupeFilterConfig{
+				ID: sink.id,
+			}
+			sources = newDedupeTestSources(map[string]*memFilesystemNode{
+				"a":     {contents: "abc", modTime: now.Add(-time.Hour)},
+				"b":     {contents: "xyz", modTime: now},
+				"empty": {},
+			}, map[string]*memFilesystemNode{
+				"c": {contents: "abc", modTime: now},
+				"d": {contents: "abcd", modTime: now},
+			})
+		})
+
+		g.JustBeforeEach(func() {
+			filter, err = NewDedupeFilter(config, sources)
+
+			Expect(err).To(BeNil())
+			Expect(filter).NotTo(BeNil())
+		})
+
+		g.Describe("calling destroy", func() {
+			g.It("should send the appropriate event", func() {
+				Expect(filter.destroy()).To(BeNil())
+
+				Expect(sink).To(haveTheseEvents(eventFilterCreated, eventFilterDestroyed))
+			})
+
+			g.It("should not destroy the input Sources", func() {
+				var destroyed = false
+				var input Source
+
+				input, err = NewSource(SourceConfig{ID: "source"}, &memFilesystem{
+					destroy: func() error {
+						destroyed = true
+
+						return nil
+					},
+					root: &memFilesystemNode{},
+				})
+
+				Expect(err).To(BeNil())
+
+				filter, err = NewDedupeFilter(config, []Source{input})
+
+				Expect(err).To(BeNil())
+				Expect(filter.destroy()).To(BeNil())
+				Expect(destroyed).To(BeFalse())
+			})
+		})
+
+		g.Context("which uses DedupePolicyFirst", func() {
+			g.Describe("calling Files", func() {
+				g.It("should suppress all but one copy of each file", func() {
+					var duplicates []Duplicate
+					var paths []string
+					var results = collectSourceResults(filter)
+
+					Expect(results).To(HaveLen(4))
+
+					duplicates = filter.Duplicates()
+
+					Expect(duplicates).To(HaveLen(1))
+					Expect(duplicates[0].Suppressed).To(HaveLen(1))
+
+					paths = []string{duplicates[0].Kept.Path().String(), duplicates[0].Suppressed[0].Path().String()}
+
+					sort.Strings(paths)
+
+					Expect(paths).To(Equal([]string{"a", "c"}))
+					Expect(resultFilePaths(results)).To(ContainElement(duplicates[0].Kept.Path().String()))
+					Expect(resultFilePaths(results)).NotTo(ContainElement(duplicates[0].Suppressed[0].Path().String()))
+					Expect(sink).To(haveTheseEvents(eventFilterCreated, eventFilterStarted,
+						eventFilterResultProduced, eventFilterResultProduced, eventFilterResultProduced,
+						eventFilterResultProduced, eventFilterFinished))
+				})
+			})
+		})
+
+		g.Context("which uses DedupePolicyNewest", func() {
+			g.BeforeEach(func() {
+				config.Policy = DedupePolicyNewest
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should keep the newest copy of each file", func() {
+					var results = collectSourceResults(filter)
+
+					Expect(resultFilePaths(results)).To(ConsistOf("b", "c", "d", "empty"))
+					Expect(filter.Duplicates()).To(HaveLen(1))
+					Expect(filter.Duplicates()[0].Kept.Path().String()).To(Equal("c"))
+				})
+			})
+		})
+
+		g.Context("which uses DedupePolicyPreferredSource", func() {
+			g.BeforeEach(func() {
+				config.Policy = DedupePolicyPreferredSource
+				config.PreferredSources = []string{"unknown", "source1", "source0"}
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should keep the copy from the preferred Source", func() {
+					var results = collectSourceResults(filter)
+
+					Expect(resultFilePaths(results)).To(ConsistOf("b", "c", "d", "empty"))
+					Expect(filter.Duplicates()).To(HaveLen(1))
+					Expect(filter.Duplicates()[0].Kept.SourceID()).To(Equal("source1"))
+					Expect(filter.Duplicates()[0].Suppressed[0].SourceID()).To(Equal("source0"))
+				})
+			})
+		})
+
+		g.Context("with files of the same size that cannot be read", func() {
+			g.BeforeEach(func() {
+				sources = newDedupeTestSources(map[string]*memFilesystemNode{
+					"a": {contents: "abc"},
+					"b": {contents: "abcd"},
+				}, map[string]*memFilesystemNode{
+					"c": {contents: "xyz"},
+				})
+
+				sources[0].(*source).fs.(*memFilesystem).readFileError = errors.New("readFile")
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should only read files whose sizes collide and return an error for the unreadable file", func() {
+					var results = collectSourceResults(filter)
+
+					Expect(results).To(HaveLen(3))
+					Expect(resultFilePaths(results)).To(ConsistOf("b", "c"))
+					Expect(filter.Duplicates()).To(BeEmpty())
+
+					for _, res := range results {
+						if res.Error() != nil {
+							Expect(res.Error()).To(beAPathError(sink.id, OperationEvaluate, "a",
+								ErrorCategoryFatal))
+						}
+					}
+				})
+			})
+		})
+
+		g.Context("with a Source that produces an error and ErrorPolicyFailFast", func() {
+			g.BeforeEach(func() {
+				config.ErrorPolicy = ErrorPolicyFailFast
+
+				sources[0].(*source).fs.(*memFilesystem).listFilesError = errors.New("listFiles")
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should only return the error", func() {
+					var results = collectSourceResults(filter)
+
+					Expect(results).To(HaveLen(1))
+					Expect(results[0].Error()).NotTo(BeNil())
+				})
+			})
+		})
+
+		g.Describe("calling Files and cancelling", func() {
+			g.It("should stop producing Results", func() {
+				var cancel CancelFunc
+				var in <-chan Result
+				var invoked = make(chan struct{})
+
+				in, cancel = filter.Files(NewContext(ContextConfig{}))
+
+				cancel(func() {
+					close(invoked)
+				})
+
+				for range in {
+				}
+
+				Eventually(invoked).Should(BeClosed())
+			})
+		})
+
+		g.Context("with files of the same size", func() {
+			var cancel chan struct{}
+			var reads int
+
+			g.BeforeEach(func() {
+				cancel = make(chan struct{})
+				reads = 0
+				sources = newDedupeTestSources(map[string]*memFilesystemNode{
+					"a": {contents: "abc"},
+					"b": {contents: "def"},
+					"c": {contents: "ghi"},
+				})
+
+				// Cancel while the first file is being digested.
+
+				sources[0].(*source).fs = &readHookMemFilesystem{
+					memFilesystem: sources[0].(*source).fs.(*memFilesystem),
+					onRead: func() {
+						if reads++; reads == 1 {
+							close(cancel)
+						}
+					},
+				}
+			})
+
+			g.Describe("calling findDuplicates and cancelling while a file is being digested", func() {
+				g.It("should not digest any more files", func() {
+					var files []File
+
+					for _, res := range collectSourceResults(sources[0]) {
+						files = append(files, res.File())
+					}
+
+					Expect(files).To(HaveLen(3))
+					Expect(filter.(*dedupeFilter).findDuplicates(files, cancel, func(res Result) bool {
+						return true
+					})).To(BeNil())
+					Expect(reads).To(Equal(1))
+					Expect(sink).To(haveTheseEvents(eventFilterCreated, eventFilterCancelled))
+				})
+			})
+		})
+	})
+
+	g.Describe("calling NewDedupeFilter", func() {
+		g.Context("with an invalid ID", func() {
+			g.It("should return an error", func() {
+				var err error
+				var filter DedupeFilter
+
+				filter, err = NewDedupeFilter(DedupeFilterConfig{ID: "invalid id"}, newDedupeTestSources())
+
+				Expect(err).NotTo(BeNil())
+				Expect(filter).To(BeNil())
+			})
+		})
+
+		g.Context("with an unknown HashAlgorithm", func() {
+			g.It("should return an error", func() {
+				var err error
+				var filter DedupeFilter
+
+				filter, err = NewDedupeFilter(DedupeFilterConfig{
+					Algorithm: HashAlgorithm(10),
+					ID:        "filter",
+				}, newDedupeTestSources())
+
+				Expect(err).To(MatchError("unknown hash algorithm: unknown(10)"))
+				Expect(filter).To(BeNil())
+			})
+		})
+
+		g.Context("with no Sources", func() {
+			g.It("should return an error", func() {
+				var err error
+				var filter DedupeFilter
+
+				filter, err = NewDedupeFilter(DedupeFilterConfig{ID: "filter"}, nil)
+
+				Expect(errors.Is(err, errSourceNone)).To(BeTrue())
+				Expect(filter).To(BeNil())
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// In-memory Filesystem implementation that calls a function whenever a file is read.
+type readHookMemFilesystem struct {
+	*memFilesystem
+
+	onRead func()
+}
+
+func (fs *readHookMemFilesystem) ReadFile(path string) (io.ReadCloser, error) {
+	fs.onRead()
+
+	return fs.memFilesystem.ReadFile(path)
+}
+
+//
+// Private functions
+//
+
+// Creates a Source for each set of files, with IDs of the form "sourceN".
+func newDedupeTestSources(children ...map[string]*memFilesystemNode) []Source {
+	var sources []Source
+
+	if len(children) == 0 {
+		children = append(children, map[string]*memFilesystemNode{
+			"a": {contents: "abc"},
+		})
+	}
+
+	for i, files := range children {
+		var src, err = NewSource(SourceConfig{ID: fmt.Sprintf("source%d", i)}, &memFilesystem{
+			root: &memFilesystemNode{
+				children: files,
+			},
+		})
+
+		Expect(err).To(BeNil())
+
+		sources = append(sources, src)
+	}
+
+	return sources
+}
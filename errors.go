@@ -3,18 +3,104 @@ package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 import (
 	"errors"
 	"fmt"
+	"os"
 )
 
 //
 // Public types
 //
 
+// ErrorCategory is used to classify errors so that consumers can decide how to react to them (e.g., retrying a
+// transient error).
+type ErrorCategory int
+
+func (category ErrorCategory) String() string {
+	switch category {
+	case ErrorCategoryNotFound:
+		return "not found"
+
+	case ErrorCategoryPermission:
+		return "permission"
+
+	case ErrorCategoryTransient:
+		return "transient"
+	}
+
+	return "fatal"
+}
+
+// ErrorPolicy determines how a Source or Filter handles the error Results it encounters.
+type ErrorPolicy int
+
 type MultiError interface {
 	Causes() []error
 
 	error
 }
 
+// PathError records the Source, operation, and path that produced an error, along with the category of the error.
+// PathErrors can be inspected using errors.Is with the ErrFatal, ErrNotFound, ErrPermission, and ErrTransient
+// sentinel errors, and the underlying cause can be retrieved using errors.Unwrap.
+type PathError interface {
+	Category() ErrorCategory
+
+	Operation() string
+
+	Path() string
+
+	SourceID() string
+
+	Unwrap() error
+
+	error
+}
+
+//
+// Public constants
+//
+
+const (
+	ErrorCategoryFatal ErrorCategory = iota
+	ErrorCategoryNotFound
+	ErrorCategoryPermission
+	ErrorCategoryTransient
+)
+
+const (
+	// ErrorPolicyForward sends error Results downstream as they are encountered.  This is the default.
+	ErrorPolicyForward ErrorPolicy = iota
+
+	// ErrorPolicyFailFast sends the first error Result downstream and then stops producing Results, cancelling any
+	// upstream Sources.
+	ErrorPolicyFailFast
+
+	// ErrorPolicySkip logs error Results using Context.Log() and then discards them.
+	ErrorPolicySkip
+
+	// ErrorPolicyCollect withholds error Results and, once all other Results have been produced, sends a single
+	// error Result containing a MultiError whose causes are every error encountered.
+	ErrorPolicyCollect
+)
+
+const (
+	OperationCheckpoint = "checkpoint"
+	OperationEvaluate   = "evaluate"
+	OperationList       = "list"
+	OperationRead       = "read"
+	OperationStat       = "stat"
+)
+
+//
+// Public variables
+//
+
+var (
+	ErrFatal      = errors.New("fatal error")
+	ErrNotFound   = errors.New("path not found")
+	ErrPermission = errors.New("permission denied")
+	ErrTransient  = errors.New("transient error")
+)
+
 //
 // Private types
 //
@@ -33,11 +119,76 @@ func (err *multiError) Error() string {
 	return err.message
 }
 
+// PathError implementation.
+type pathError struct {
+	category  ErrorCategory
+	cause     error
+	operation string
+	path      string
+	sourceID  string
+}
+
+func (err *pathError) Category() ErrorCategory {
+	return err.category
+}
+
+func (err *pathError) Error() string {
+	var message = err.operation
+
+	if err.path != "" {
+		message += " " + err.path
+	}
+
+	message += ": " + err.cause.Error()
+
+	if err.sourceID != "" {
+		message = err.sourceID + ": " + message
+	}
+
+	return message
+}
+
+func (err *pathError) Is(target error) bool {
+	switch target {
+	case ErrFatal:
+		return err.category == ErrorCategoryFatal
+
+	case ErrNotFound:
+		return err.category == ErrorCategoryNotFound
+
+	case ErrPermission:
+		return err.category == ErrorCategoryPermission
+
+	case ErrTransient:
+		return err.category == ErrorCategoryTransient
+	}
+
+	return false
+}
+
+func (err *pathError) Operation() string {
+	return err.operation
+}
+
+func (err *pathError) Path() string {
+	return err.path
+}
+
+func (err *pathError) SourceID() string {
+	return err.sourceID
+}
+
+func (err *pathError) Unwrap() error {
+	return err.cause
+}
+
 //
 // Private variables
 //
 
 var (
+	errFileNotSeekable     = errors.New("file does not support random access")
+	errSourceNotRooted     = errors.New("a Source with a templated root path requires a RootedFilesystem")
 	errSourceNilFilesystem = errors.New("cannot create Source using nil Filesystem")
 	errSourceNone          = errors.New("no Sources provided")
 )
@@ -46,6 +197,36 @@ var (
 // Private functions
 //
 
+// Determines the ErrorCategory of an error by inspecting the error chain.
+func categorize(err error) ErrorCategory {
+	var pathErr PathError
+	var temporary interface {
+		Temporary() bool
+	}
+	var timeout interface {
+		Timeout() bool
+	}
+
+	switch {
+	case errors.As(err, &pathErr):
+		return pathErr.Category()
+
+	case errors.Is(err, os.ErrNotExist):
+		return ErrorCategoryNotFound
+
+	case errors.Is(err, os.ErrPermission):
+		return ErrorCategoryPermission
+
+	case errors.As(err, &temporary) && temporary.Temporary():
+		return ErrorCategoryTransient
+
+	case errors.As(err, &timeout) && timeout.Timeout():
+		return ErrorCategoryTransient
+	}
+
+	return ErrorCategoryFatal
+}
+
 func newMultiError(message string, causes []error) MultiError {
 	if causes == nil {
 		causes = make([]error, 0)
@@ -57,6 +238,16 @@ func newMultiError(message string, causes []error) MultiError {
 	}
 }
 
+func newPathError(sourceID, operation, path string, cause error) error {
+	return &pathError{
+		category:  categorize(cause),
+		cause:     cause,
+		operation: operation,
+		path:      path,
+		sourceID:  sourceID,
+	}
+}
+
 func newPanicError(value interface{}) error {
 	var message = "a fatal error occurred: "
 
@@ -67,3 +258,27 @@ func newPanicError(value interface{}) error {
 		return fmt.Errorf(message+"%v", val)
 	}
 }
+
+// Ensures that an error is a PathError that refers to the given Source ID, wrapping it if necessary.
+func withSourceID(sourceID, operation string, err error) error {
+	var copied pathError
+	var ok bool
+	var pathErr *pathError
+
+	if err == nil {
+		return nil
+	}
+
+	if pathErr, ok = err.(*pathError); !ok {
+		return newPathError(sourceID, operation, "", err)
+	}
+
+	if pathErr.sourceID != "" {
+		return pathErr
+	}
+
+	copied = *pathErr
+	copied.sourceID = sourceID
+
+	return &copied
+}
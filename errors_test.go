@@ -2,6 +2,9 @@ package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"syscall"
 
 	g "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -63,6 +66,110 @@ var _ = g.Describe("MultiError", func() {
 	})
 })
 
+// PathError tests
+
+var _ = g.Describe("PathError", func() {
+	g.Describe("calling newPathError", func() {
+		var err error
+
+		g.Context("with a cause that indicates a missing path", func() {
+			g.BeforeEach(func() {
+				err = newPathError("source", OperationStat, "a/b", &os.PathError{
+					Op:   "stat",
+					Path: "a/b",
+					Err:  syscall.ENOENT,
+				})
+			})
+
+			g.It("should return a PathError in the not found category", func() {
+				Expect(err).To(beAPathError("source", OperationStat, "a/b", ErrorCategoryNotFound))
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+				Expect(errors.Is(err, ErrFatal)).To(BeFalse())
+				Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+			})
+
+			g.Describe("and then calling Error", func() {
+				g.It("should return a message containing the Source ID, operation, and path", func() {
+					Expect(err.Error()).To(Equal("source: stat a/b: stat a/b: no such file or directory"))
+				})
+			})
+		})
+
+		g.Context("with a cause that indicates a permission problem", func() {
+			g.BeforeEach(func() {
+				err = newPathError("source", OperationRead, "a", syscall.EACCES)
+			})
+
+			g.It("should return a PathError in the permission category", func() {
+				Expect(err).To(beAPathError("source", OperationRead, "a", ErrorCategoryPermission))
+				Expect(errors.Is(err, ErrPermission)).To(BeTrue())
+			})
+		})
+
+		g.Context("with a cause that indicates a transient problem", func() {
+			g.BeforeEach(func() {
+				err = newPathError("source", OperationList, "a", fmt.Errorf("wrapped: %w", syscall.ETIMEDOUT))
+			})
+
+			g.It("should return a PathError in the transient category", func() {
+				Expect(err).To(beAPathError("source", OperationList, "a", ErrorCategoryTransient))
+				Expect(errors.Is(err, ErrTransient)).To(BeTrue())
+				Expect(errors.Is(err, syscall.ETIMEDOUT)).To(BeTrue())
+			})
+		})
+
+		g.Context("with any other cause", func() {
+			g.BeforeEach(func() {
+				err = newPathError("", OperationList, "", errors.New("other"))
+			})
+
+			g.It("should return a PathError in the fatal category", func() {
+				Expect(err).To(beAPathError("", OperationList, "", ErrorCategoryFatal))
+				Expect(errors.Is(err, ErrFatal)).To(BeTrue())
+			})
+
+			g.Describe("and then calling Error", func() {
+				g.It("should return a message containing only the operation", func() {
+					Expect(err.Error()).To(Equal("list: other"))
+				})
+			})
+		})
+	})
+
+	g.Describe("calling withSourceID", func() {
+		g.Context("with a nil error", func() {
+			g.It("should return nil", func() {
+				Expect(withSourceID("source", OperationList, nil)).To(BeNil())
+			})
+		})
+
+		g.Context("with an error that is not a PathError", func() {
+			g.It("should wrap the error", func() {
+				Expect(withSourceID("source", OperationList, errors.New("error"))).To(beAPathError("source",
+					OperationList, "", ErrorCategoryFatal))
+			})
+		})
+
+		g.Context("with a PathError that does not have a Source ID", func() {
+			g.It("should return a copy of the PathError with the Source ID set", func() {
+				var original = newPathError("", OperationStat, "a", syscall.ENOENT)
+
+				Expect(withSourceID("source", OperationList, original)).To(beAPathError("source", OperationStat, "a",
+					ErrorCategoryNotFound))
+				Expect(original).To(beAPathError("", OperationStat, "a", ErrorCategoryNotFound))
+			})
+		})
+
+		g.Context("with a PathError that already has a Source ID", func() {
+			g.It("should return the same PathError", func() {
+				var original = newPathError("other", OperationStat, "a", syscall.ENOENT)
+
+				Expect(withSourceID("source", OperationList, original)).To(BeIdenticalTo(original))
+			})
+		})
+	})
+})
+
 // Other error tests
 
 var _ = g.Describe("newPanicError", func() {
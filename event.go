@@ -1,6 +1,7 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	"golang.handcraftedbits.com/pipewerx/events"
 	"golang.handcraftedbits.com/pipewerx/internal/event"
 )
 
@@ -9,9 +10,9 @@ import (
 //
 
 const (
-	componentFile   = "file"
-	componentFilter = "filter"
-	componentSource = "source"
+	componentFile   = events.ComponentFile
+	componentFilter = events.ComponentFilter
+	componentSource = events.ComponentSource
 )
 
 //
@@ -26,7 +27,13 @@ func newResultProducedEvent(component, id string, result Result) event.Event {
 	}
 
 	if result.File() != nil {
+		var attributes = attributesToMap(result.File().Attributes())
+
 		evt.Data()[event.FieldFile] = result.File().Path().String()
+
+		if len(attributes) > 0 {
+			evt.Data()[event.FieldAttributes] = attributes
+		}
 	}
 
 	return evt
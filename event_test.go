@@ -143,6 +143,29 @@ var _ = g.Describe("Source events", func() {
 
 			Expect(sourceEventResultProduced(id, res)).To(beAValidEvent(componentSource, event.TypeResultProduced, id))
 		})
+
+		g.It("should include the Attributes of the File", func() {
+			var evt event.Event
+			var f = &file{
+				fileInfo: &nilFileInfo{
+					name: "name",
+				},
+				path: newFilePath(nil, "name", "/"),
+			}
+
+			evt = sourceEventResultProduced(id, &result{file: f})
+
+			Expect(evt.Data()).NotTo(HaveKey(event.FieldAttributes))
+
+			f.Attributes().Set(AttributeKeyContentType, "text/plain")
+
+			evt = sourceEventResultProduced(id, &result{file: f})
+
+			Expect(evt).To(beAValidEvent(componentSource, event.TypeResultProduced, id))
+			Expect(evt.Data()).To(HaveKeyWithValue(event.FieldAttributes, map[string]interface{}{
+				AttributeKeyContentType: "text/plain",
+			}))
+		})
 	})
 
 	g.Describe("calling sourceEventStarted", func() {
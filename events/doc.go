@@ -0,0 +1,7 @@
+// Package events delivers the events produced by files, Filters, and Sources to Listeners.
+//
+// Events from a component are only produced once AllowFrom has allowed that component or a Listener registered with
+// RegisterFrom needs them.  The sinks that ship with pipewerx (JSONSink, metrics.PrometheusSink, pipewerx.LogSink, and
+// pipewerx.ProgressTracker) register with RegisterFrom, so they receive the events they need until they are stopped or
+// closed without changing which events are delivered to any other Listener.
+package events // import "golang.handcraftedbits.com/pipewerx/events"
@@ -0,0 +1,300 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"time"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Public types
+//
+
+// Event is implemented by every typed event.  Listeners are expected to use a type switch to determine which event
+// they have received.
+type Event interface {
+	// Component returns the type of component that produced the event (e.g., ComponentSource).
+	Component() string
+
+	// ID returns the ID of the component that produced the event.  For file events, this is the ID of the Source that
+	// produced the file.
+	ID() string
+
+	// Sequence returns a number that increases monotonically with every event produced by the process.
+	Sequence() uint64
+
+	// Time returns the time at which the event was produced.
+	Time() time.Time
+}
+
+type FileClosed struct {
+	header
+
+	Path string
+}
+
+type FileOpened struct {
+	header
+
+	Path string
+	Size int64
+}
+
+type FileRead struct {
+	header
+
+	Bytes int
+	Path  string
+}
+
+type FilterCancelled struct {
+	header
+}
+
+type FilterCreated struct {
+	header
+}
+
+type FilterDestroyed struct {
+	header
+}
+
+type FilterFinished struct {
+	header
+}
+
+type FilterStarted struct {
+	header
+}
+
+// Listener receives typed events.
+type Listener interface {
+	Handle(event Event)
+}
+
+// ListenerFunc allows an ordinary function to be used as a Listener.
+type ListenerFunc func(event Event)
+
+func (fn ListenerFunc) Handle(event Event) {
+	fn(event)
+}
+
+// ResultProduced is produced whenever a Source or Filter sends a Result downstream.  Component can be used to
+// determine which kind of component produced the Result.
+type ResultProduced struct {
+	header
+
+	// Attributes contains the Attributes of the File at the time the Result was produced, or nil if the File had
+	// none.
+	Attributes map[string]interface{}
+
+	Error string
+	Path  string
+}
+
+type SourceCancelled struct {
+	header
+}
+
+type SourceCreated struct {
+	header
+}
+
+type SourceDestroyed struct {
+	header
+}
+
+type SourceFinished struct {
+	header
+}
+
+type SourceStarted struct {
+	header
+}
+
+//
+// Public constants
+//
+
+const (
+	ComponentFile   = "file"
+	ComponentFilter = "filter"
+	ComponentSource = "source"
+)
+
+//
+// Public functions
+//
+
+// AllowFrom determines whether or not events produced by a given component are delivered to Listeners.  Events from
+// all components are disallowed by default.
+func AllowFrom(component string, shouldAllow bool) {
+	event.AllowFrom(component, shouldAllow)
+}
+
+// Register causes a Listener to receive all allowed events.  The returned function can be called to stop delivering
+// events to the Listener.
+func Register(listener Listener) func() {
+	return RegisterFrom(listener)
+}
+
+// RegisterFrom is like Register, but the Listener also receives events from the given components even if they are not
+// allowed by AllowFrom.  Other Listeners are unaffected.
+func RegisterFrom(listener Listener, components ...string) func() {
+	if listener == nil {
+		return func() {}
+	}
+
+	return event.RegisterSinkFrom(&listenerSink{
+		listener: listener,
+	}, components...)
+}
+
+//
+// Private types
+//
+
+// Common Event implementation that is embedded in every typed event.
+type header struct {
+	component string
+	id        string
+	sequence  uint64
+	time      time.Time
+}
+
+func (h header) Component() string {
+	return h.component
+}
+
+func (h header) ID() string {
+	return h.id
+}
+
+func (h header) Sequence() uint64 {
+	return h.sequence
+}
+
+func (h header) Time() time.Time {
+	return h.time
+}
+
+// event.Sink implementation that converts internal events into typed events before passing them to a Listener.
+type listenerSink struct {
+	listener Listener
+}
+
+func (sink *listenerSink) Send(evt event.Event) {
+	var converted = convert(evt)
+
+	if converted != nil {
+		sink.listener.Handle(converted)
+	}
+}
+
+//
+// Private functions
+//
+
+// Converts an internal event into a typed event, returning nil if the event is not recognized.
+func convert(evt event.Event) Event {
+	var h = header{
+		component: evt.Component(),
+		id:        stringField(evt, event.FieldID),
+		sequence:  evt.Sequence(),
+		time:      evt.Time(),
+	}
+
+	switch evt.Component() {
+	case ComponentFile:
+		switch evt.Type() {
+		case event.TypeClosed:
+			return FileClosed{header: h, Path: stringField(evt, event.FieldFile)}
+
+		case event.TypeOpened:
+			return FileOpened{header: h, Path: stringField(evt, event.FieldFile), Size: intField(evt,
+				event.FieldLength)}
+
+		case event.TypeRead:
+			return FileRead{header: h, Bytes: int(intField(evt, event.FieldLength)), Path: stringField(evt,
+				event.FieldFile)}
+		}
+
+	case ComponentFilter:
+		switch evt.Type() {
+		case event.TypeCancelled:
+			return FilterCancelled{header: h}
+
+		case event.TypeCreated:
+			return FilterCreated{header: h}
+
+		case event.TypeDestroyed:
+			return FilterDestroyed{header: h}
+
+		case event.TypeFinished:
+			return FilterFinished{header: h}
+
+		case event.TypeResultProduced:
+			return newResultProduced(h, evt)
+
+		case event.TypeStarted:
+			return FilterStarted{header: h}
+		}
+
+	case ComponentSource:
+		switch evt.Type() {
+		case event.TypeCancelled:
+			return SourceCancelled{header: h}
+
+		case event.TypeCreated:
+			return SourceCreated{header: h}
+
+		case event.TypeDestroyed:
+			return SourceDestroyed{header: h}
+
+		case event.TypeFinished:
+			return SourceFinished{header: h}
+
+		case event.TypeResultProduced:
+			return newResultProduced(h, evt)
+
+		case event.TypeStarted:
+			return SourceStarted{header: h}
+		}
+	}
+
+	return nil
+}
+
+// Retrieves a numeric field from an internal event.  Numbers may be stored using any integer type, or float64 if the
+// event was unmarshalled from JSON.
+func intField(evt event.Event, field string) int64 {
+	switch value := evt.Data()[field].(type) {
+	case int:
+		return int64(value)
+
+	case int64:
+		return value
+
+	case float64:
+		return int64(value)
+	}
+
+	return 0
+}
+
+func newResultProduced(h header, evt event.Event) ResultProduced {
+	var attributes, _ = evt.Data()[event.FieldAttributes].(map[string]interface{})
+
+	return ResultProduced{
+		header:     h,
+		Attributes: attributes,
+		Error:      stringField(evt, event.FieldError),
+		Path:       stringField(evt, event.FieldFile),
+	}
+}
+
+func stringField(evt event.Event, field string) string {
+	var value, _ = evt.Data()[field].(string)
+
+	return value
+}
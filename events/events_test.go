@@ -0,0 +1,263 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// Conversion tests
+
+var _ = Describe("convert", func() {
+	Describe("calling convert", func() {
+		var id = "id"
+
+		Context("with file events", func() {
+			It("should return the expected typed events", func() {
+				var converted Event
+				var evt = event.WithID(ComponentFile, id, event.TypeOpened)
+
+				evt.Data()[event.FieldFile] = "a/b.c"
+				evt.Data()[event.FieldLength] = int64(10)
+
+				converted = convert(evt)
+
+				Expect(converted).To(Equal(FileOpened{header: headerOf(evt), Path: "a/b.c", Size: 10}))
+
+				evt = event.WithID(ComponentFile, id, event.TypeRead)
+
+				evt.Data()[event.FieldFile] = "a/b.c"
+				evt.Data()[event.FieldLength] = 5
+
+				Expect(convert(evt)).To(Equal(FileRead{header: headerOf(evt), Bytes: 5, Path: "a/b.c"}))
+
+				evt = event.WithID(ComponentFile, id, event.TypeClosed)
+
+				evt.Data()[event.FieldFile] = "a/b.c"
+
+				Expect(convert(evt)).To(Equal(FileClosed{header: headerOf(evt), Path: "a/b.c"}))
+			})
+
+			It("should handle lengths that were unmarshalled from JSON", func() {
+				var contents []byte
+				var err error
+				var evt = event.WithID(ComponentFile, id, event.TypeRead)
+				var unmarshalled = event.WithID("", "", "")
+
+				evt.Data()[event.FieldFile] = "a/b.c"
+				evt.Data()[event.FieldLength] = 5
+
+				contents, err = json.Marshal(evt)
+
+				Expect(err).To(BeNil())
+
+				err = json.Unmarshal(contents, unmarshalled)
+
+				Expect(err).To(BeNil())
+				Expect(convert(unmarshalled).(FileRead).Bytes).To(Equal(5))
+			})
+		})
+
+		Context("with Filter events", func() {
+			It("should return the expected typed events", func() {
+				var evt event.Event
+
+				for eventType, expected := range map[string]interface{}{
+					event.TypeCancelled: FilterCancelled{},
+					event.TypeCreated:   FilterCreated{},
+					event.TypeDestroyed: FilterDestroyed{},
+					event.TypeFinished:  FilterFinished{},
+					event.TypeStarted:   FilterStarted{},
+				} {
+					evt = event.WithID(ComponentFilter, id, eventType)
+
+					Expect(convert(evt)).To(BeAssignableToTypeOf(expected))
+					Expect(convert(evt).Component()).To(Equal(ComponentFilter))
+					Expect(convert(evt).ID()).To(Equal(id))
+					Expect(convert(evt).Sequence()).To(Equal(evt.Sequence()))
+					Expect(convert(evt).Time()).To(Equal(evt.Time()))
+				}
+			})
+		})
+
+		Context("with Source events", func() {
+			It("should return the expected typed events", func() {
+				var evt event.Event
+
+				for eventType, expected := range map[string]interface{}{
+					event.TypeCancelled: SourceCancelled{},
+					event.TypeCreated:   SourceCreated{},
+					event.TypeDestroyed: SourceDestroyed{},
+					event.TypeFinished:  SourceFinished{},
+					event.TypeStarted:   SourceStarted{},
+				} {
+					evt = event.WithID(ComponentSource, id, eventType)
+
+					Expect(convert(evt)).To(BeAssignableToTypeOf(expected))
+					Expect(convert(evt).Component()).To(Equal(ComponentSource))
+					Expect(convert(evt).ID()).To(Equal(id))
+				}
+			})
+		})
+
+		Context("with Result events", func() {
+			It("should return the expected typed events", func() {
+				var evt = event.WithID(ComponentSource, id, event.TypeResultProduced)
+
+				evt.Data()[event.FieldError] = "error"
+				evt.Data()[event.FieldFile] = "a/b.c"
+
+				Expect(convert(evt)).To(Equal(ResultProduced{header: headerOf(evt), Error: "error", Path: "a/b.c"}))
+
+				evt = event.WithID(ComponentFilter, id, event.TypeResultProduced)
+
+				evt.Data()[event.FieldFile] = "a/b.c"
+
+				Expect(convert(evt)).To(Equal(ResultProduced{header: headerOf(evt), Path: "a/b.c"}))
+
+				evt.Data()[event.FieldAttributes] = map[string]interface{}{"key": "value"}
+
+				Expect(convert(evt)).To(Equal(ResultProduced{header: headerOf(evt), Attributes: map[string]interface{}{
+					"key": "value",
+				}, Path: "a/b.c"}))
+			})
+		})
+
+		Context("with an unknown event", func() {
+			It("should return nil", func() {
+				Expect(convert(event.WithID("unknown", id, event.TypeCreated))).To(BeNil())
+				Expect(convert(event.WithID(ComponentSource, id, "unknown"))).To(BeNil())
+			})
+		})
+	})
+})
+
+// Registration tests
+
+var _ = Describe("Register", func() {
+	Describe("calling Register", func() {
+		Context("with a nil Listener", func() {
+			It("should return a function that does nothing", func() {
+				var unregister = Register(nil)
+
+				Expect(unregister).NotTo(BeNil())
+
+				unregister()
+			})
+		})
+
+		Context("with a valid Listener", func() {
+			It("should deliver allowed events until unregistered", func() {
+				var listener = newTestListener()
+				var unregister func()
+
+				AllowFrom(ComponentSource, true)
+
+				unregister = Register(listener)
+
+				event.Send(event.WithID(ComponentSource, listener.id, event.TypeCreated))
+				event.Send(event.WithID(ComponentSource, listener.id, event.TypeStarted))
+
+				unregister()
+				unregister()
+
+				event.Send(event.WithID(ComponentSource, listener.id, event.TypeFinished))
+
+				listener.mutex.Lock()
+				defer listener.mutex.Unlock()
+
+				Expect(listener.events).To(HaveLen(2))
+				Expect(listener.events[0]).To(BeAssignableToTypeOf(SourceCreated{}))
+				Expect(listener.events[1]).To(BeAssignableToTypeOf(SourceStarted{}))
+				Expect(listener.events[1].Sequence()).To(BeNumerically(">", listener.events[0].Sequence()))
+			})
+		})
+	})
+})
+
+var _ = Describe("RegisterFrom", func() {
+	Describe("calling RegisterFrom", func() {
+		Context("with a component that is not allowed", func() {
+			It("should only deliver events from the component to the Listener that registered for it", func() {
+				var listener = newTestListener()
+				var other = newTestListener()
+				var unregister func()
+				var unregisterOther = Register(other)
+
+				defer unregisterOther()
+
+				AllowFrom(ComponentFilter, false)
+
+				unregister = RegisterFrom(listener, ComponentFilter)
+
+				event.Send(event.WithID(ComponentFilter, listener.id, event.TypeCreated))
+				event.Send(event.WithID(ComponentFilter, other.id, event.TypeCreated))
+
+				unregister()
+
+				listener.mutex.Lock()
+				defer listener.mutex.Unlock()
+
+				other.mutex.Lock()
+				defer other.mutex.Unlock()
+
+				Expect(listener.events).To(HaveLen(1))
+				Expect(listener.events[0]).To(BeAssignableToTypeOf(FilterCreated{}))
+				Expect(other.events).To(BeEmpty())
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// Listener implementation used to capture events for a single ID.
+type testListener struct {
+	events []Event
+	id     string
+	mutex  sync.Mutex
+}
+
+func (listener *testListener) Handle(evt Event) {
+	if evt.ID() != listener.id {
+		return
+	}
+
+	listener.mutex.Lock()
+
+	listener.events = append(listener.events, evt)
+
+	listener.mutex.Unlock()
+}
+
+//
+// Private functions
+//
+
+func headerOf(evt event.Event) header {
+	return header{
+		component: evt.Component(),
+		id:        evt.Data()[event.FieldID].(string),
+		sequence:  evt.Sequence(),
+		time:      evt.Time(),
+	}
+}
+
+func newTestListener() *testListener {
+	return &testListener{
+		id:    fmt.Sprintf("%d", rand.Int()),
+		mutex: sync.Mutex{},
+	}
+}
@@ -0,0 +1,242 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Public types
+//
+
+// JSONSink writes every event as a single line of JSON.
+type JSONSink interface {
+	// Close stops the JSONSink from receiving events and closes the underlying io.Writer if it is also an io.Closer.
+	// The first error encountered while writing events, if any, is returned.
+	Close() error
+}
+
+// RotatingFileConfig is used to configure an io.WriteCloser that rotates files based on their size.
+type RotatingFileConfig struct {
+	// MaxBackups is the number of rotated files to keep.  Rotated files are named by appending ".1", ".2", etc. to
+	// Path, with ".1" being the most recent.  Zero means that rotated files are discarded.
+	MaxBackups int
+
+	// MaxSize is the size, in bytes, at which the file is rotated.  Zero means that the file is never rotated.
+	MaxSize int64
+
+	Path string
+}
+
+//
+// Public functions
+//
+
+// NewJSONSink creates a JSONSink that starts writing events to an io.Writer immediately.
+func NewJSONSink(writer io.Writer) JSONSink {
+	var sink = &jsonSink{
+		encoder: json.NewEncoder(writer),
+		writer:  writer,
+	}
+
+	sink.unregister = event.RegisterSinkFrom(sink, ComponentFile, ComponentFilter, ComponentSource)
+
+	return sink
+}
+
+// NewRotatingFile creates an io.WriteCloser that appends to a file, rotating it whenever a write would cause it to
+// exceed the configured maximum size.  Each write is kept intact, so rotation only occurs between lines written by a
+// JSONSink.
+func NewRotatingFile(config RotatingFileConfig) (io.WriteCloser, error) {
+	var writer = &rotatingFile{
+		config: config,
+	}
+
+	if err := writer.open(); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// Replay reads events written by a JSONSink and passes them, in order, to a Listener.
+func Replay(reader io.Reader, listener Listener) error {
+	var line = 0
+	var scanner = bufio.NewScanner(reader)
+
+	scanner.Buffer(make([]byte, 0, replayBufferSize), replayMaxLineSize)
+
+	for scanner.Scan() {
+		var converted Event
+		var err error
+		var evt event.Event
+
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if evt, err = event.Unmarshal(scanner.Bytes()); err != nil {
+			return fmt.Errorf("unable to parse event on line %d: %w", line, err)
+		}
+
+		if converted = convert(evt); converted != nil {
+			listener.Handle(converted)
+		}
+	}
+
+	return scanner.Err()
+}
+
+//
+// Private constants
+//
+
+const (
+	replayBufferSize  = 64 * 1024
+	replayMaxLineSize = 16 * 1024 * 1024
+)
+
+//
+// Private types
+//
+
+// JSONSink implementation
+type jsonSink struct {
+	encoder    *json.Encoder
+	err        error
+	mutex      sync.Mutex
+	once       sync.Once
+	unregister func()
+	writer     io.Writer
+}
+
+func (sink *jsonSink) Close() error {
+	var err error
+
+	sink.once.Do(func() {
+		sink.unregister()
+
+		sink.mutex.Lock()
+		defer sink.mutex.Unlock()
+
+		if closer, ok := sink.writer.(io.Closer); ok {
+			err = closer.Close()
+		}
+
+		if sink.err == nil {
+			sink.err = err
+		}
+	})
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	return sink.err
+}
+
+func (sink *jsonSink) Send(evt event.Event) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	// json.Encoder terminates every value with a newline, so each event ends up on its own line.
+
+	if err := sink.encoder.Encode(evt); err != nil && sink.err == nil {
+		sink.err = err
+	}
+}
+
+// io.WriteCloser implementation that rotates a file based on its size.
+type rotatingFile struct {
+	config RotatingFileConfig
+	file   *os.File
+	mutex  sync.Mutex
+	size   int64
+}
+
+func (writer *rotatingFile) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.file.Close()
+}
+
+func (writer *rotatingFile) Write(p []byte) (int, error) {
+	var amount int
+	var err error
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.config.MaxSize > 0 && writer.size > 0 && writer.size+int64(len(p)) > writer.config.MaxSize {
+		if err = writer.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	amount, err = writer.file.Write(p)
+
+	writer.size += int64(amount)
+
+	return amount, err
+}
+
+func (writer *rotatingFile) backupPath(index int) string {
+	return fmt.Sprintf("%s.%d", writer.config.Path, index)
+}
+
+func (writer *rotatingFile) open() error {
+	var err error
+	var fileInfo os.FileInfo
+
+	writer.file, err = os.OpenFile(writer.config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if fileInfo, err = writer.file.Stat(); err != nil {
+		_ = writer.file.Close()
+
+		return err
+	}
+
+	writer.size = fileInfo.Size()
+
+	return nil
+}
+
+func (writer *rotatingFile) rotate() error {
+	if err := writer.file.Close(); err != nil {
+		return err
+	}
+
+	if writer.config.MaxBackups <= 0 {
+		if err := os.Remove(writer.config.Path); err != nil {
+			return err
+		}
+
+		return writer.open()
+	}
+
+	// Shift existing backups up by one, discarding the oldest.
+
+	for i := writer.config.MaxBackups - 1; i > 0; i-- {
+		if err := os.Rename(writer.backupPath(i), writer.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(writer.config.Path, writer.backupPath(1)); err != nil {
+		return err
+	}
+
+	return writer.open()
+}
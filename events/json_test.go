@@ -0,0 +1,234 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// JSONSink tests
+
+var _ = Describe("JSONSink", func() {
+	Describe("given a new instance", func() {
+		var buffer *bytes.Buffer
+		var listener *testListener
+		var sink JSONSink
+
+		BeforeEach(func() {
+			buffer = new(bytes.Buffer)
+			listener = newTestListener()
+			sink = NewJSONSink(buffer)
+		})
+
+		AfterEach(func() {
+			Expect(sink.Close()).To(BeNil())
+		})
+
+		Context("which has received events", func() {
+			BeforeEach(func() {
+				var evt event.Event
+
+				event.Send(event.WithID(ComponentSource, listener.id, event.TypeStarted))
+
+				evt = event.WithID(ComponentFile, listener.id, event.TypeRead)
+				evt.Data()[event.FieldFile] = "a"
+				evt.Data()[event.FieldLength] = 3
+
+				event.Send(evt)
+			})
+
+			It("should have written each event on its own line", func() {
+				var lines = 0
+
+				for _, line := range strings.Split(buffer.String(), "\n") {
+					if strings.Contains(line, `"id":"`+listener.id+`"`) {
+						lines++
+					}
+				}
+
+				Expect(lines).To(Equal(2))
+			})
+
+			Describe("calling Replay", func() {
+				It("should reconstruct the events", func() {
+					Expect(Replay(bytes.NewReader(buffer.Bytes()), listener)).To(BeNil())
+
+					Expect(listener.events).To(HaveLen(2))
+					Expect(listener.events[0]).To(BeAssignableToTypeOf(SourceStarted{}))
+					Expect(listener.events[1]).To(BeAssignableToTypeOf(FileRead{}))
+					Expect(listener.events[1].(FileRead).Bytes).To(Equal(3))
+					Expect(listener.events[1].(FileRead).Path).To(Equal("a"))
+					Expect(listener.events[1].Sequence()).To(BeNumerically(">", listener.events[0].Sequence()))
+				})
+			})
+		})
+
+		Describe("calling Close", func() {
+			It("should stop writing events", func() {
+				Expect(sink.Close()).To(BeNil())
+
+				event.Send(event.WithID(ComponentSource, listener.id, event.TypeStarted))
+
+				Expect(buffer.String()).NotTo(ContainSubstring(listener.id))
+			})
+
+			It("should no longer allow events that were only allowed by the JSONSink", func() {
+				Expect(event.IsAllowedFrom(ComponentFilter)).To(BeTrue())
+				Expect(sink.Close()).To(BeNil())
+				Expect(event.IsAllowedFrom(ComponentFilter)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("given a new instance that uses an io.Writer which fails", func() {
+		It("should return the error when closed", func() {
+			var sink = NewJSONSink(&failingWriter{})
+
+			event.Send(event.WithID(ComponentSource, "id", event.TypeStarted))
+
+			Expect(sink.Close()).To(MatchError("write"))
+		})
+	})
+})
+
+var _ = Describe("Replay", func() {
+	Describe("calling Replay", func() {
+		Context("with invalid JSON", func() {
+			It("should return an error", func() {
+				var err = Replay(strings.NewReader("\n{"), ListenerFunc(func(event Event) {}))
+
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(HavePrefix("unable to parse event on line 2"))
+			})
+		})
+
+		Context("with a recorded event", func() {
+			It("should keep the recorded sequence number without allocating a new one", func() {
+				var listener = newTestListener()
+				var sequence = event.WithID(ComponentSource, listener.id, event.TypeStarted).Sequence()
+
+				Expect(Replay(strings.NewReader(`{"component":"source","data":{"id":"`+listener.id+
+					`"},"sequence":7,"time":"2020-01-01T00:00:00Z","type":"started"}`), listener)).To(BeNil())
+
+				Expect(listener.events).To(HaveLen(1))
+				Expect(listener.events[0].ID()).To(Equal(listener.id))
+				Expect(listener.events[0].Sequence()).To(BeEquivalentTo(7))
+				Expect(event.WithID(ComponentSource, listener.id, event.TypeStarted).Sequence()).To(Equal(sequence + 1))
+			})
+		})
+	})
+})
+
+// Rotating file tests
+
+var _ = Describe("NewRotatingFile", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+
+		dir, err = ioutil.TempDir("", "pipewerx")
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(BeNil())
+	})
+
+	Describe("calling NewRotatingFile", func() {
+		Context("with a path that cannot be opened", func() {
+			It("should return an error", func() {
+				var err error
+				var writer io.WriteCloser
+
+				writer, err = NewRotatingFile(RotatingFileConfig{
+					Path: filepath.Join(dir, "missing", "log"),
+				})
+
+				Expect(err).NotTo(BeNil())
+				Expect(writer).To(BeNil())
+			})
+		})
+
+		Context("with a maximum size and number of backups", func() {
+			It("should rotate the file and discard the oldest backups", func() {
+				var err error
+				var path = filepath.Join(dir, "log")
+				var writer io.WriteCloser
+
+				writer, err = NewRotatingFile(RotatingFileConfig{
+					MaxBackups: 2,
+					MaxSize:    4,
+					Path:       path,
+				})
+
+				Expect(err).To(BeNil())
+
+				for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n", "7\n"} {
+					_, err = writer.Write([]byte(line))
+
+					Expect(err).To(BeNil())
+				}
+
+				Expect(writer.Close()).To(BeNil())
+
+				Expect(ioutil.ReadFile(path)).To(BeEquivalentTo("7\n"))
+				Expect(ioutil.ReadFile(path + ".1")).To(BeEquivalentTo("5\n6\n"))
+				Expect(ioutil.ReadFile(path + ".2")).To(BeEquivalentTo("3\n4\n"))
+				Expect(path + ".3").NotTo(BeAnExistingFile())
+			})
+		})
+
+		Context("with a maximum size and no backups", func() {
+			It("should truncate the file", func() {
+				var err error
+				var path = filepath.Join(dir, "log")
+				var writer io.WriteCloser
+
+				writer, err = NewRotatingFile(RotatingFileConfig{
+					MaxSize: 4,
+					Path:    path,
+				})
+
+				Expect(err).To(BeNil())
+
+				for _, line := range []string{"1\n", "2\n", "3\n"} {
+					_, err = writer.Write([]byte(line))
+
+					Expect(err).To(BeNil())
+				}
+
+				Expect(writer.Close()).To(BeNil())
+
+				Expect(ioutil.ReadFile(path)).To(BeEquivalentTo("3\n"))
+				Expect(path + ".1").NotTo(BeAnExistingFile())
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// io.Writer implementation that always fails.
+type failingWriter struct {
+}
+
+func (writer *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write")
+}
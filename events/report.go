@@ -0,0 +1,114 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+//
+// Public types
+//
+
+// FileReport describes the reading of a single file.
+type FileReport struct {
+	BytesRead int64
+	Closed    bool
+	Opened    time.Time
+	Path      string
+
+	// SourceID is the ID of the Source that produced the file.
+	SourceID string
+}
+
+// Report summarizes a pipeline run.  A Report is a Listener, so it can be built while the pipeline is running or
+// afterwards by replaying a log written by a JSONSink.
+type Report struct {
+	// Errors contains an entry for every error Result produced by a Source or Filter.
+	Errors []ResultReport
+
+	// Produced contains an entry for every file Result produced by a Source or Filter.
+	Produced []ResultReport
+
+	// Read contains an entry for every time a file was opened for reading, in the order in which the files were opened.
+	Read []*FileReport
+
+	mutex sync.Mutex
+	open  map[string][]*FileReport
+}
+
+func (report *Report) Handle(evt Event) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	if report.open == nil {
+		report.open = make(map[string][]*FileReport)
+	}
+
+	switch typed := evt.(type) {
+	case FileClosed:
+		var key = typed.ID() + "/" + typed.Path
+
+		if len(report.open[key]) > 0 {
+			report.open[key][0].Closed = true
+			report.open[key] = report.open[key][1:]
+		}
+
+	case FileOpened:
+		var fileReport = &FileReport{
+			Opened:   typed.Time(),
+			Path:     typed.Path,
+			SourceID: typed.ID(),
+		}
+		var key = typed.ID() + "/" + typed.Path
+
+		report.Read = append(report.Read, fileReport)
+		report.open[key] = append(report.open[key], fileReport)
+
+	case FileRead:
+		var key = typed.ID() + "/" + typed.Path
+
+		if len(report.open[key]) > 0 {
+			report.open[key][0].BytesRead += int64(typed.Bytes)
+		}
+
+	case ResultProduced:
+		var resultReport = ResultReport{
+			Component: typed.Component(),
+			Error:     typed.Error,
+			ID:        typed.ID(),
+			Path:      typed.Path,
+			Time:      typed.Time(),
+		}
+
+		if typed.Error != "" {
+			report.Errors = append(report.Errors, resultReport)
+		} else {
+			report.Produced = append(report.Produced, resultReport)
+		}
+	}
+}
+
+// ResultReport describes a single Result produced by a Source or Filter.
+type ResultReport struct {
+	Component string
+	Error     string
+	ID        string
+	Path      string
+	Time      time.Time
+}
+
+//
+// Public functions
+//
+
+// NewReportFromLog creates a Report by replaying a log written by a JSONSink.
+func NewReportFromLog(reader io.Reader) (*Report, error) {
+	var report = &Report{}
+
+	if err := Replay(reader, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
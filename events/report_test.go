@@ -0,0 +1,106 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// Report tests
+
+var _ = Describe("Report", func() {
+	Describe("calling NewReportFromLog", func() {
+		Context("with a log written by a JSONSink", func() {
+			It("should reconstruct the run", func() {
+				var buffer = new(bytes.Buffer)
+				var err error
+				var id = newTestListener().id
+				var report *Report
+				var sink = NewJSONSink(buffer)
+
+				for _, evt := range []event.Event{
+					newTestEvent(ComponentSource, id, event.TypeResultProduced, "a", 0),
+					newTestEvent(ComponentSource, id, event.TypeResultProduced, "", 0),
+					newTestEvent(ComponentFile, id, event.TypeOpened, "a", 6),
+					newTestEvent(ComponentFile, id, event.TypeRead, "a", 4),
+					newTestEvent(ComponentFile, id, event.TypeRead, "a", 2),
+					newTestEvent(ComponentFile, id, event.TypeClosed, "a", 0),
+				} {
+					event.Send(evt)
+				}
+
+				Expect(sink.Close()).To(BeNil())
+
+				// Other tests may be sending events at the same time, so only keep the ones we're interested in.
+
+				report, err = NewReportFromLog(strings.NewReader(linesContaining(buffer.String(), id)))
+
+				Expect(err).To(BeNil())
+				Expect(report.Produced).To(HaveLen(1))
+				Expect(report.Produced[0].Component).To(Equal(ComponentSource))
+				Expect(report.Produced[0].ID).To(Equal(id))
+				Expect(report.Produced[0].Path).To(Equal("a"))
+				Expect(report.Errors).To(HaveLen(1))
+				Expect(report.Errors[0].Error).To(Equal("error"))
+				Expect(report.Read).To(HaveLen(1))
+				Expect(report.Read[0].BytesRead).To(BeEquivalentTo(6))
+				Expect(report.Read[0].Closed).To(BeTrue())
+				Expect(report.Read[0].Path).To(Equal("a"))
+				Expect(report.Read[0].SourceID).To(Equal(id))
+			})
+		})
+
+		Context("with an invalid log", func() {
+			It("should return an error", func() {
+				var err error
+				var report *Report
+
+				report, err = NewReportFromLog(strings.NewReader("invalid"))
+
+				Expect(err).NotTo(BeNil())
+				Expect(report).To(BeNil())
+			})
+		})
+	})
+})
+
+//
+// Private functions
+//
+
+func linesContaining(contents, value string) string {
+	var result []string
+
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.Contains(line, value) {
+			result = append(result, line)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// Creates an event with a file path and length.  Result events without a path are treated as errors.
+func newTestEvent(component, id, eventType, path string, length int) event.Event {
+	var evt = event.WithID(component, id, eventType)
+
+	if eventType == event.TypeResultProduced && path == "" {
+		evt.Data()[event.FieldError] = "error"
+	} else {
+		evt.Data()[event.FieldFile] = path
+	}
+
+	if length > 0 {
+		evt.Data()[event.FieldLength] = length
+	}
+
+	return evt
+}
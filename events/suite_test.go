@@ -0,0 +1,18 @@
+package events // import "golang.handcraftedbits.com/pipewerx/events"
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+func TestSuiteEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "events")
+}
@@ -1,13 +1,18 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	gocontext "context"
 	"fmt"
 	"io"
 	"os"
 	pathutil "path"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/api/trace"
+	"golang.org/x/time/rate"
+
 	"golang.handcraftedbits.com/pipewerx/internal/event"
 )
 
@@ -18,9 +23,15 @@ import (
 type File interface {
 	os.FileInfo
 
+	// Attributes returns the values that have been attached to this File as it moved through a pipeline.
+	Attributes() Attributes
+
 	Path() FilePath
 
 	Reader() (io.ReadCloser, error)
+
+	// SourceID returns the ID of the Source that produced this File.
+	SourceID() string
 }
 
 type FileEvaluator interface {
@@ -106,44 +117,221 @@ type Result interface {
 	File() File
 }
 
+// RootedFilesystem is implemented by Filesystems that are tied to the root path of the Source that uses them (e.g.,
+// because ReadFile() resolves paths against it).  A Source whose root path is a template (see ExpandTemplate())
+// requires a RootedFilesystem, since its root path isn't known until Files() is called.
+type RootedFilesystem interface {
+	Filesystem
+
+	// WithRoot returns a Filesystem that uses the given root path.  The returned Filesystem shares any resources
+	// (e.g., connections) with this one, so it doesn't need to be destroyed separately.
+	WithRoot(root string) Filesystem
+}
+
+// SeekableFile is implemented by Files that can provide random access to their contents.  Whether random access is
+// actually available depends on the File's Filesystem, so Seekable() should be checked before calling
+// SeekableReader().
+type SeekableFile interface {
+	File
+
+	Seekable() bool
+
+	// SeekableReader is like Reader, but returns a SeekableReader.  Since the contents can be read in any order,
+	// digests (see SourceConfig.Hashes) aren't computed.
+	SeekableReader() (SeekableReader, error)
+}
+
+// SeekableFilesystem is implemented by Filesystems that can provide random access to file contents.
+type SeekableFilesystem interface {
+	Filesystem
+
+	// ReadFileSeekable is like ReadFile, but returns a SeekableReader.
+	ReadFileSeekable(path string) (SeekableReader, error)
+}
+
+// SeekableReader provides random access to the contents of a file.
+type SeekableReader interface {
+	io.Closer
+	io.ReaderAt
+	io.ReadSeeker
+}
+
 //
 // Private types
 //
 
-// io.ReadCloser implementation that produces Events detailing file read progress.
+// io.ReadCloser implementation that produces Events detailing file read progress, computes digests if requested, and
+// ends a tracing span when closed.
 type eventProducingReadCloser struct {
-	file     File
-	sourceID string
-	wrapped  io.ReadCloser
+	file         File
+	hasher       *fileHasher
+	readContext  gocontext.Context
+	readLimiter  *rate.Limiter
+	sourceID     string
+	span         trace.Span
+	traceContext gocontext.Context
+	wrapped      io.ReadCloser
 }
 
 func (reader *eventProducingReadCloser) Read(p []byte) (int, error) {
 	var amount int
 	var err error
 
+	if p, err = reader.beforeRead(p); err != nil {
+		return 0, reader.afterRead(0, err)
+	}
+
 	amount, err = reader.wrapped.Read(p)
 
-	if amount > 0 && event.IsAllowedFrom(componentFile) {
-		event.Send(fileEventRead(reader.file, reader.sourceID, amount))
+	// Not every io.Reader honours the contract, so don't let a negative count reach the hasher or the events.
+
+	if amount < 0 {
+		amount = 0
 	}
 
-	return amount, err
+	if reader.hasher != nil {
+		reader.hasher.write(p[:amount])
+
+		if err == io.EOF {
+			reader.hasher.attach(true)
+		}
+	}
+
+	return amount, reader.afterRead(amount, err)
 }
 
 func (reader *eventProducingReadCloser) Close() error {
+	var err error
+
+	// Readers aren't required to read until io.EOF, so attach the digests if every byte has been read.
+
+	if reader.hasher != nil {
+		reader.hasher.attach(false)
+	}
+
 	if event.IsAllowedFrom(componentFile) {
 		event.Send(fileEventClosed(reader.file, reader.sourceID))
 	}
 
-	return reader.wrapped.Close()
+	err = reader.wrapped.Close()
+
+	if err != nil {
+		reader.span.RecordError(reader.traceContext, err)
+	}
+
+	reader.span.End()
+
+	return err
+}
+
+// Throttles, records errors, and sends Events after data has been read.
+func (reader *eventProducingReadCloser) afterRead(amount int, err error) error {
+	// Rate limiting happens after the fact, since the amount that will be read isn't known ahead of time.  Waiting
+	// stops if the Source is cancelled.
+
+	if waitErr := waitForTokens(reader.readContext, reader.readLimiter, amount); err == nil {
+		err = waitErr
+	}
+
+	if err != nil && err != io.EOF {
+		err = newPathError(reader.sourceID, OperationRead, reader.file.Path().String(), err)
+
+		reader.span.RecordError(reader.traceContext, err)
+	}
+
+	if amount > 0 && event.IsAllowedFrom(componentFile) {
+		event.Send(fileEventRead(reader.file, reader.sourceID, amount))
+	}
+
+	return err
+}
+
+// Limits the amount of data that can be read at once to the rate limit's burst size, so that reading into a large
+// buffer can't get ahead of the rate limit, and stops rate limited reads once the Source has been cancelled.
+func (reader *eventProducingReadCloser) beforeRead(p []byte) ([]byte, error) {
+	if reader.readLimiter == nil {
+		return p, nil
+	}
+
+	if err := reader.readContext.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p) > reader.readLimiter.Burst() {
+		p = p[:reader.readLimiter.Burst()]
+	}
+
+	return p, nil
+}
+
+// SeekableReader implementation that produces Events detailing file read progress.
+type eventProducingSeekableReader struct {
+	*eventProducingReadCloser
+
+	seekable SeekableReader
+}
+
+func (reader *eventProducingSeekableReader) ReadAt(p []byte, offset int64) (int, error) {
+	var amount int
+	var err error
+
+	// Unlike Read, ReadAt must fill the buffer unless an error occurs, so read as many chunks as it takes.
+
+	for amount < len(p) && err == nil {
+		var chunk []byte
+		var read int
+
+		if chunk, err = reader.beforeRead(p[amount:]); err != nil {
+			return amount, reader.afterRead(0, err)
+		}
+
+		if read, err = reader.seekable.ReadAt(chunk, offset+int64(amount)); read < 0 {
+			read = 0
+		}
+
+		amount += read
+		err = reader.afterRead(read, err)
+	}
+
+	return amount, err
+}
+
+func (reader *eventProducingSeekableReader) Seek(offset int64, whence int) (int64, error) {
+	var err error
+	var position int64
+
+	position, err = reader.seekable.Seek(offset, whence)
+
+	if err != nil {
+		err = newPathError(reader.sourceID, OperationRead, reader.file.Path().String(), err)
+
+		reader.span.RecordError(reader.traceContext, err)
+	}
+
+	return position, err
 }
 
 // File implementation
 type file struct {
-	fileInfo os.FileInfo
-	fs       Filesystem
-	path     FilePath
-	sourceID string
+	attributes     *attributes
+	attributesOnce sync.Once
+	fileInfo       os.FileInfo
+	fs             Filesystem
+	hashes         []HashAlgorithm
+	path           FilePath
+	readContext    gocontext.Context
+	readLimiter    *rate.Limiter
+	sourceID       string
+	traceContext   gocontext.Context
+	tracer         trace.Tracer
+}
+
+func (f *file) Attributes() Attributes {
+	f.attributesOnce.Do(func() {
+		f.attributes = newAttributes()
+	})
+
+	return f.attributes
 }
 
 func (f *file) IsDir() bool {
@@ -174,22 +362,50 @@ func (f *file) Path() FilePath {
 
 func (f *file) Reader() (io.ReadCloser, error) {
 	var err error
-	var reader io.ReadCloser
-
-	reader, err = f.fs.ReadFile(f.path.String())
+	var producer *eventProducingReadCloser
 
-	if err != nil {
+	if producer, err = f.newReader(f.fs.ReadFile); err != nil {
 		return nil, err
 	}
 
-	if event.IsAllowedFrom(componentFile) {
-		event.Send(fileEventOpened(f, f.sourceID))
+	if len(f.hashes) > 0 {
+		producer.hasher = newFileHasher(f, f.hashes)
 	}
 
-	return &eventProducingReadCloser{
-		file:     f,
-		sourceID: f.sourceID,
-		wrapped:  reader,
+	return producer, nil
+}
+
+func (f *file) Seekable() bool {
+	var _, ok = f.fs.(SeekableFilesystem)
+
+	return ok
+}
+
+func (f *file) SeekableReader() (SeekableReader, error) {
+	var err error
+	var fs, ok = f.fs.(SeekableFilesystem)
+	var producer *eventProducingReadCloser
+	var seekable SeekableReader
+
+	if !ok {
+		return nil, newPathError(f.sourceID, OperationRead, f.path.String(), errFileNotSeekable)
+	}
+
+	producer, err = f.newReader(func(path string) (io.ReadCloser, error) {
+		var err error
+
+		seekable, err = fs.ReadFileSeekable(path)
+
+		return seekable, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventProducingSeekableReader{
+		eventProducingReadCloser: producer,
+		seekable:                 seekable,
 	}, nil
 }
 
@@ -197,10 +413,57 @@ func (f *file) Size() int64 {
 	return f.fileInfo.Size()
 }
 
+func (f *file) SourceID() string {
+	return f.sourceID
+}
+
 func (f *file) Sys() interface{} {
 	return f.fileInfo.Sys()
 }
 
+// Opens the File using the given function and wraps the result so that it produces Events and tracing spans.
+func (f *file) newReader(readFile func(path string) (io.ReadCloser, error)) (*eventProducingReadCloser, error) {
+	var err error
+	var readContext = f.readContext
+	var reader io.ReadCloser
+	var span trace.Span
+	var traceContext gocontext.Context
+
+	if readContext == nil {
+		readContext = gocontext.Background()
+	}
+
+	// The span lasts for the lifetime of the reader, so it is ended when the reader is closed.
+
+	traceContext, span = startSpanFromTraceContext(f.tracer, f.traceContext, SpanFileReader,
+		traceAttributeSourceID(f.sourceID), traceAttributePath(f.path.String()))
+
+	reader, err = readFile(f.path.String())
+
+	if err != nil {
+		err = newPathError(f.sourceID, OperationRead, f.path.String(), err)
+
+		span.RecordError(traceContext, err)
+		span.End()
+
+		return nil, err
+	}
+
+	if event.IsAllowedFrom(componentFile) {
+		event.Send(fileEventOpened(f, f.sourceID))
+	}
+
+	return &eventProducingReadCloser{
+		file:         f,
+		readContext:  readContext,
+		readLimiter:  f.readLimiter,
+		sourceID:     f.sourceID,
+		span:         span,
+		traceContext: traceContext,
+		wrapped:      reader,
+	}, nil
+}
+
 // FilePath implementation
 type filePath struct {
 	dir       []string
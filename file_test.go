@@ -55,9 +55,126 @@ var _ = g.Describe("File", func() {
 					reader, err = f.Reader()
 
 					Expect(err).NotTo(BeNil())
+					Expect(err).To(beAPathError("", OperationRead, "name", ErrorCategoryFatal))
 					Expect(reader).To(BeNil())
 				})
 			})
+
+			g.Describe("calling Seekable", func() {
+				g.It("should return false", func() {
+					Expect(f.(SeekableFile).Seekable()).To(BeFalse())
+				})
+			})
+
+			g.Describe("calling SeekableReader", func() {
+				g.It("should return an error", func() {
+					var err error
+					var reader SeekableReader
+
+					reader, err = f.(SeekableFile).SeekableReader()
+
+					Expect(errors.Is(err, errFileNotSeekable)).To(BeTrue())
+					Expect(err).To(beAPathError("", OperationRead, "name", ErrorCategoryFatal))
+					Expect(reader).To(BeNil())
+				})
+			})
+		})
+
+		g.Context("for a file with a Filesystem that supports random access", func() {
+			var f *file
+
+			g.BeforeEach(func() {
+				f = &file{
+					fileInfo: &nilFileInfo{
+						name: "name",
+						size: 6,
+					},
+					fs: &seekableMemFilesystem{
+						memFilesystem: &memFilesystem{
+							root: &memFilesystemNode{
+								children: map[string]*memFilesystemNode{
+									"name": {
+										contents: "abcdef",
+									},
+								},
+							},
+						},
+					},
+					path: newFilePath(nil, "name", "/"),
+				}
+			})
+
+			g.Describe("calling Seekable", func() {
+				g.It("should return true", func() {
+					Expect(f.Seekable()).To(BeTrue())
+				})
+			})
+
+			g.Describe("calling SeekableReader", func() {
+				var sink *testEventSink
+
+				g.JustBeforeEach(func() {
+					sink = newTestEventSink()
+
+					event.RegisterSink(sink)
+
+					f.sourceID = sink.id
+				})
+
+				g.It("should return the expected file contents and send the appropriate events", func() {
+					var amount int
+					var contents = make([]byte, 2)
+					var err error
+					var position int64
+					var reader SeekableReader
+
+					reader, err = f.SeekableReader()
+
+					Expect(reader).NotTo(BeNil())
+					Expect(err).To(BeNil())
+
+					amount, err = reader.ReadAt(contents, 2)
+
+					Expect(amount).To(Equal(2))
+					Expect(err).To(BeNil())
+					Expect(string(contents)).To(Equal("cd"))
+
+					position, err = reader.Seek(4, io.SeekStart)
+
+					Expect(position).To(BeEquivalentTo(4))
+					Expect(err).To(BeNil())
+
+					amount, err = reader.Read(contents)
+
+					Expect(amount).To(Equal(2))
+					Expect(err).To(BeNil())
+					Expect(string(contents)).To(Equal("ef"))
+
+					Expect(reader.Close()).To(BeNil())
+
+					Expect(sink.events).To(HaveLen(4))
+					Expect(sink.events[0].Type()).To(Equal(event.TypeOpened))
+					Expect(sink.events[1].Data()[event.FieldLength]).To(BeEquivalentTo(2))
+					Expect(sink.events[1].Type()).To(Equal(event.TypeRead))
+					Expect(sink.events[2].Data()[event.FieldLength]).To(BeEquivalentTo(2))
+					Expect(sink.events[2].Type()).To(Equal(event.TypeRead))
+					Expect(sink.events[3].Type()).To(Equal(event.TypeClosed))
+				})
+
+				g.It("should return an error that includes the path if seeking fails", func() {
+					var err error
+					var reader SeekableReader
+
+					reader, err = f.SeekableReader()
+
+					Expect(err).To(BeNil())
+
+					_, err = reader.Seek(-1, io.SeekStart)
+
+					Expect(err).To(beAPathError(sink.id, OperationRead, "name", ErrorCategoryFatal))
+					Expect(reader.Close()).To(BeNil())
+				})
+			})
 		})
 
 		g.Context("for a file with no extension", func() {
@@ -529,6 +646,7 @@ func (fs *memFilesystem) findNode(path string) *memFilesystemNode {
 type memFilesystemNode struct {
 	children map[string]*memFilesystemNode
 	contents string
+	modTime  time.Time
 	name     string
 }
 
@@ -537,7 +655,7 @@ func (node *memFilesystemNode) Name() string {
 }
 
 func (node *memFilesystemNode) Size() int64 {
-	return 0
+	return int64(len(node.contents))
 }
 
 func (node *memFilesystemNode) Mode() os.FileMode {
@@ -545,7 +663,11 @@ func (node *memFilesystemNode) Mode() os.FileMode {
 }
 
 func (node *memFilesystemNode) ModTime() time.Time {
-	return time.Now()
+	if node.modTime.IsZero() {
+		return time.Now()
+	}
+
+	return node.modTime
 }
 
 func (node *memFilesystemNode) IsDir() bool {
@@ -556,6 +678,45 @@ func (node *memFilesystemNode) Sys() interface{} {
 	return nil
 }
 
+// In-memory RootedFilesystem implementation that records the root paths it is given.
+type rootedMemFilesystem struct {
+	*memFilesystem
+
+	roots []string
+}
+
+func (fs *rootedMemFilesystem) WithRoot(root string) Filesystem {
+	fs.roots = append(fs.roots, root)
+
+	return fs
+}
+
+// In-memory SeekableFilesystem implementation.
+type seekableMemFilesystem struct {
+	*memFilesystem
+}
+
+func (fs *seekableMemFilesystem) ReadFileSeekable(path string) (SeekableReader, error) {
+	var node = fs.findNode(path)
+
+	if node == nil {
+		return nil, memFilesystemErrorNotFound
+	}
+
+	return &seekableMemReader{
+		Reader: strings.NewReader(node.contents),
+	}, nil
+}
+
+// In-memory SeekableReader implementation.
+type seekableMemReader struct {
+	*strings.Reader
+}
+
+func (reader *seekableMemReader) Close() error {
+	return nil
+}
+
 // Dummy os.FileInfo implementation used to test file and fileInfoStack.
 type nilFileInfo struct {
 	name string
@@ -590,4 +751,4 @@ func (fi *nilFileInfo) Sys() interface{} {
 // Private variables
 //
 
-var memFilesystemErrorNotFound = fmt.Errorf("path not found")
+var memFilesystemErrorNotFound = fmt.Errorf("path not found: %w", os.ErrNotExist)
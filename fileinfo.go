@@ -0,0 +1,95 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"os"
+	"time"
+)
+
+//
+// Public types
+//
+
+// DOSAttributes is a set of the attributes that Windows filesystems record for a file.
+type DOSAttributes uint32
+
+// Has determines whether every one of the given attributes is set.
+func (attributes DOSAttributes) Has(other DOSAttributes) bool {
+	return attributes&other == other
+}
+
+type DOSAttributesEvaluatorConfig struct {
+	// Attributes contains the DOSAttributes a File must have to match.  A File matches if it has every one of them.
+	Attributes DOSAttributes
+
+	// Exclude determines whether matching Files are dropped.  If Exclude is false, only matching Files are kept.
+	Exclude bool
+}
+
+// ExtendedFileInfo contains information about a file that isn't provided by os.FileInfo.  Filesystems that can provide
+// it return an *ExtendedFileInfo from os.FileInfo.Sys(), which can be retrieved using GetExtendedFileInfo().  Fields
+// that a Filesystem can't provide are left unset.
+type ExtendedFileInfo struct {
+	AccessTime    time.Time
+	ChangeTime    time.Time
+	CreationTime  time.Time
+	DOSAttributes DOSAttributes
+
+	// SecurityDescriptor is the file's security descriptor (owner, group, and access control list) in the textual
+	// format used by Samba (e.g., "REVISION:1,OWNER:...,GROUP:...,ACL:...").
+	SecurityDescriptor string
+}
+
+//
+// Public constants
+//
+
+// These match the values used by Windows.
+const (
+	DOSAttributeReadOnly DOSAttributes = 0x01
+	DOSAttributeHidden   DOSAttributes = 0x02
+	DOSAttributeSystem   DOSAttributes = 0x04
+	DOSAttributeArchive  DOSAttributes = 0x20
+)
+
+//
+// Public functions
+//
+
+// GetExtendedFileInfo retrieves the ExtendedFileInfo for a file, returning nil if its Filesystem doesn't provide any.
+func GetExtendedFileInfo(fileInfo os.FileInfo) *ExtendedFileInfo {
+	var extended, _ = fileInfo.Sys().(*ExtendedFileInfo)
+
+	return extended
+}
+
+// NewDOSAttributesEvaluator creates a FileEvaluator that keeps or drops Files based on their DOSAttributes.  Files
+// whose Filesystem doesn't provide an ExtendedFileInfo are considered to have no DOSAttributes.
+func NewDOSAttributesEvaluator(config DOSAttributesEvaluatorConfig) FileEvaluator {
+	return &dosAttributesEvaluator{
+		config: config,
+	}
+}
+
+//
+// Private types
+//
+
+// FileEvaluator implementation that checks the DOSAttributes of Files
+type dosAttributesEvaluator struct {
+	config DOSAttributesEvaluatorConfig
+}
+
+func (evaluator *dosAttributesEvaluator) Destroy() error {
+	return nil
+}
+
+func (evaluator *dosAttributesEvaluator) ShouldKeep(file File) (bool, error) {
+	var attributes DOSAttributes
+	var extended = GetExtendedFileInfo(file)
+
+	if extended != nil {
+		attributes = extended.DOSAttributes
+	}
+
+	return attributes.Has(evaluator.config.Attributes) != evaluator.config.Exclude, nil
+}
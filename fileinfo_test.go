@@ -0,0 +1,112 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// DOSAttributes tests
+
+var _ = g.Describe("DOSAttributes", func() {
+	g.Describe("calling Has", func() {
+		g.It("should return true only if every attribute is set", func() {
+			var attributes = DOSAttributeArchive | DOSAttributeHidden
+
+			Expect(attributes.Has(DOSAttributeArchive)).To(BeTrue())
+			Expect(attributes.Has(DOSAttributeArchive | DOSAttributeHidden)).To(BeTrue())
+			Expect(attributes.Has(DOSAttributeArchive | DOSAttributeSystem)).To(BeFalse())
+			Expect(attributes.Has(0)).To(BeTrue())
+		})
+	})
+})
+
+// GetExtendedFileInfo tests
+
+var _ = g.Describe("GetExtendedFileInfo", func() {
+	g.It("should return the ExtendedFileInfo if the Filesystem provides one", func() {
+		var extended = &ExtendedFileInfo{
+			DOSAttributes: DOSAttributeReadOnly,
+		}
+
+		Expect(GetExtendedFileInfo(newExtendedTestFile(extended))).To(BeIdenticalTo(extended))
+	})
+
+	g.It("should return nil if the Filesystem doesn't provide one", func() {
+		Expect(GetExtendedFileInfo(newExtendedTestFile(nil))).To(BeNil())
+		Expect(GetExtendedFileInfo(&nilFileInfo{})).To(BeNil())
+	})
+})
+
+// NewDOSAttributesEvaluator tests
+
+var _ = g.Describe("NewDOSAttributesEvaluator", func() {
+	var archived = newExtendedTestFile(&ExtendedFileInfo{
+		DOSAttributes: DOSAttributeArchive | DOSAttributeReadOnly,
+	})
+	var hidden = newExtendedTestFile(&ExtendedFileInfo{
+		DOSAttributes: DOSAttributeHidden,
+	})
+	var plain = newExtendedTestFile(nil)
+
+	g.Context("with Exclude unset", func() {
+		g.It("should keep only Files that have every attribute", func() {
+			var evaluator = NewDOSAttributesEvaluator(DOSAttributesEvaluatorConfig{
+				Attributes: DOSAttributeArchive,
+			})
+
+			Expect(evaluator.ShouldKeep(archived)).To(BeTrue())
+			Expect(evaluator.ShouldKeep(hidden)).To(BeFalse())
+			Expect(evaluator.ShouldKeep(plain)).To(BeFalse())
+			Expect(evaluator.Destroy()).To(BeNil())
+		})
+	})
+
+	g.Context("with Exclude set", func() {
+		g.It("should drop Files that have every attribute", func() {
+			var evaluator = NewDOSAttributesEvaluator(DOSAttributesEvaluatorConfig{
+				Attributes: DOSAttributeHidden,
+				Exclude:    true,
+			})
+
+			Expect(evaluator.ShouldKeep(archived)).To(BeTrue())
+			Expect(evaluator.ShouldKeep(hidden)).To(BeFalse())
+			Expect(evaluator.ShouldKeep(plain)).To(BeTrue())
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// os.FileInfo implementation that returns an ExtendedFileInfo from Sys().
+type extendedFileInfo struct {
+	nilFileInfo
+
+	extended *ExtendedFileInfo
+}
+
+func (fi *extendedFileInfo) Sys() interface{} {
+	if fi.extended == nil {
+		return nil
+	}
+
+	return fi.extended
+}
+
+//
+// Private functions
+//
+
+func newExtendedTestFile(extended *ExtendedFileInfo) File {
+	return &file{
+		fileInfo: &extendedFileInfo{
+			extended: extended,
+		},
+		path: newFilePath(nil, "file.txt", "/"),
+	}
+}
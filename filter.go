@@ -1,5 +1,7 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 import (
+	"go.opentelemetry.io/otel/api/trace"
+
 	"golang.handcraftedbits.com/pipewerx/internal/event"
 )
 
@@ -12,7 +14,8 @@ type Filter interface {
 }
 
 type FilterConfig struct {
-	ID string
+	ErrorPolicy ErrorPolicy
+	ID          string
 }
 
 //
@@ -73,12 +76,17 @@ func (f *filter) Files(context Context) (<-chan Result, CancelFunc) {
 	var cancel = make(chan struct{})
 	var cancelHelper *cancellationHelper
 	var out = make(chan Result)
+	var span trace.Span
+
+	context, span = startSpan(context, SpanFilterFiles, traceAttributeFilterID(f.ID()))
 
-	cancelHelper = newCancellationHelper(context.Log(), out, cancel, nil)
+	cancelHelper = newCancellationHelper(context.Log(), componentFilter, f.ID(), out, cancel, nil)
 
 	go func() {
 		var err error
+		var errorHelper = newErrorPolicyHelper(context.Log(), f.ID(), f.config.ErrorPolicy)
 		var in <-chan Result
+		var send func(res Result) bool
 		var sourceCancel CancelFunc
 
 		if event.IsAllowedFrom(componentFilter) {
@@ -90,49 +98,84 @@ func (f *filter) Files(context Context) (<-chan Result, CancelFunc) {
 				event.Send(filterEventFinished(f.ID()))
 			}
 
+			span.End()
+
 			cancelHelper.finalize()
 		}()
 
+		send = func(res Result) bool {
+			select {
+			case out <- res:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventResultProduced(f.ID(), res))
+				}
+
+				if res.Error() != nil {
+					span.RecordError(traceContextOf(context), res.Error())
+				}
+
+				return true
+
+			case <-cancel:
+				if event.IsAllowedFrom(componentFilter) {
+					event.Send(filterEventCancelled(f.ID()))
+				}
+
+				return false
+			}
+		}
+
 		in, sourceCancel = f.input.Files(context)
 
 		for res := range in {
 			var keep bool
+			var stop bool
 
 			if res.Error() == nil {
 				func() {
+					var evaluatorContext, evaluatorSpan = startSpanFromTraceContext(tracerOf(context),
+						traceContextOf(context), SpanFilterShouldKeep, traceAttributeFilterID(f.ID()),
+						traceAttributePath(res.File().Path().String()))
+
+					defer evaluatorSpan.End()
+
 					defer func() {
 						if value := recover(); value != nil {
 							err = newPanicError(value)
 							keep = false
 						}
+
+						if err != nil {
+							evaluatorSpan.RecordError(evaluatorContext, err)
+						}
 					}()
 
 					keep, err = f.evaluator.ShouldKeep(res.File())
 				}()
 
 				if err != nil {
-					res = &result{err: err, file: nil}
+					res = &result{
+						err: newPathError(f.ID(), OperationEvaluate, res.File().Path().String(), err),
+					}
 				}
 			}
 
-			if keep || res.Error() != nil {
-				select {
-				case out <- res:
-					if event.IsAllowedFrom(componentFilter) {
-						event.Send(filterEventResultProduced(f.ID(), res))
-					}
-
-				case <-cancel:
-					if event.IsAllowedFrom(componentFilter) {
-						event.Send(filterEventCancelled(f.ID()))
-					}
+			if res.Error() != nil {
+				res, stop = errorHelper.handle(res)
+			} else if !keep {
+				continue
+			}
 
-					sourceCancel(nil)
+			if (res != nil && !send(res)) || stop {
+				sourceCancel(nil)
 
-					return
-				}
+				return
 			}
 		}
+
+		if res := errorHelper.finish(); res != nil {
+			send(res)
+		}
 	}()
 
 	return out, cancelHelper.invoker()
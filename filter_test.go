@@ -1,6 +1,7 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	"bytes"
 	"errors"
 	"sync"
 
@@ -155,7 +156,10 @@ var _ = g.Describe("Filter", func() {
 					Expect(results).To(HaveLen(1))
 					Expect(results[0].File()).To(BeNil())
 					Expect(results[0].Error()).NotTo(BeNil())
-					Expect(results[0].Error().Error()).To(Equal("a fatal error occurred: shouldKeep"))
+					Expect(results[0].Error().Error()).To(Equal(sink.id +
+						": evaluate file1.keep: a fatal error occurred: shouldKeep"))
+					Expect(results[0].Error()).To(beAPathError(sink.id, OperationEvaluate, "file1.keep",
+						ErrorCategoryFatal))
 
 					Expect(sink).To(haveTheseEvents(eventFilterCreated, eventFilterStarted, eventFilterResultProduced,
 						eventFilterFinished))
@@ -165,6 +169,125 @@ var _ = g.Describe("Filter", func() {
 	})
 })
 
+var _ = g.Describe("Filter error policies", func() {
+	g.Describe("given a new instance which uses a FileEvaluator that always returns an error", func() {
+		var buffer *bytes.Buffer
+		var err error
+		var filter Filter
+		var policy ErrorPolicy
+		var results []Result
+		var sink *testEventSink
+
+		g.JustBeforeEach(func() {
+			var in <-chan Result
+			var source Source
+
+			buffer = new(bytes.Buffer)
+			sink = newTestEventSink()
+
+			event.RegisterSink(sink)
+
+			source, err = NewSource(SourceConfig{ID: "source"}, &memFilesystem{
+				root: &memFilesystemNode{
+					children: map[string]*memFilesystemNode{
+						"file1": {},
+						"file2": {},
+						"file3": {},
+					},
+				},
+			})
+
+			Expect(err).To(BeNil())
+			Expect(source).NotTo(BeNil())
+
+			filter, err = NewFilter(FilterConfig{
+				ErrorPolicy: policy,
+				ID:          sink.id,
+			}, []Source{source}, &extensionFileEvaluator{
+				shouldKeepError: errors.New("shouldKeep"),
+			})
+
+			Expect(err).To(BeNil())
+			Expect(filter).NotTo(BeNil())
+
+			results = make([]Result, 0)
+
+			in, _ = filter.Files(NewContext(ContextConfig{
+				Writer: buffer,
+			}))
+
+			for result := range in {
+				results = append(results, result)
+			}
+		})
+
+		g.Context("with the forward policy", func() {
+			g.BeforeEach(func() {
+				policy = ErrorPolicyForward
+			})
+
+			g.It("should return every error Result", func() {
+				Expect(results).To(HaveLen(3))
+
+				for _, result := range results {
+					var path = result.Error().(PathError).Path()
+
+					Expect(result.Error()).To(beAPathError(sink.id, OperationEvaluate, path, ErrorCategoryFatal))
+				}
+			})
+		})
+
+		g.Context("with the fail-fast policy", func() {
+			g.BeforeEach(func() {
+				policy = ErrorPolicyFailFast
+			})
+
+			g.It("should return only the first error Result", func() {
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].Error()).NotTo(BeNil())
+
+				Expect(sink).To(haveTheseEvents(eventFilterCreated, eventFilterStarted, eventFilterResultProduced,
+					eventFilterFinished))
+			})
+		})
+
+		g.Context("with the skip policy", func() {
+			g.BeforeEach(func() {
+				policy = ErrorPolicySkip
+			})
+
+			g.It("should return no Results and log every error", func() {
+				Expect(results).To(HaveLen(0))
+				Expect(bytes.Count(buffer.Bytes(), []byte("discarding an error Result"))).To(Equal(3))
+			})
+		})
+
+		g.Context("with the collect policy", func() {
+			g.BeforeEach(func() {
+				policy = ErrorPolicyCollect
+			})
+
+			g.It("should return a single Result containing every error", func() {
+				var multiErr MultiError
+				var ok bool
+
+				Expect(results).To(HaveLen(1))
+				Expect(results[0].File()).To(BeNil())
+
+				multiErr, ok = results[0].Error().(MultiError)
+
+				Expect(ok).To(BeTrue())
+				Expect(multiErr.Causes()).To(HaveLen(3))
+				Expect(multiErr.Error()).To(HavePrefix("'" + sink.id + "' finished with 3 error(s):"))
+
+				for _, cause := range multiErr.Causes() {
+					Expect(multiErr.Error()).To(ContainSubstring(cause.Error()))
+				}
+			})
+		})
+	})
+})
+
 var _ = g.Describe("NewFilter", func() {
 	g.Describe("calling NewFilter", func() {
 		var err error
@@ -3,8 +3,16 @@ module golang.handcraftedbits.com/pipewerx
 go 1.13
 
 require (
+	github.com/cespare/xxhash/v2 v2.1.1
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/hirochachacha/go-smb2 v1.1.0
 	github.com/onsi/ginkgo v1.12.0
 	github.com/onsi/gomega v1.9.0
 	github.com/ory/dockertest/v3 v3.5.4
+	github.com/prometheus/client_golang v1.5.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/rs/zerolog v1.18.0
+	go.etcd.io/bbolt v1.3.4
+	go.opentelemetry.io/otel v0.4.3
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 )
@@ -0,0 +1,231 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+//
+// Public types
+//
+
+// HashAlgorithm identifies an algorithm that can be used to compute the digest of a File's contents.
+type HashAlgorithm int
+
+// AttributeKey returns the key of the File attribute that holds the hex-encoded digest computed using this
+// HashAlgorithm.
+func (algorithm HashAlgorithm) AttributeKey() string {
+	return "hash." + algorithm.String()
+}
+
+func (algorithm HashAlgorithm) String() string {
+	switch algorithm {
+	case HashAlgorithmMD5:
+		return "md5"
+
+	case HashAlgorithmSHA1:
+		return "sha1"
+
+	case HashAlgorithmSHA256:
+		return "sha256"
+
+	case HashAlgorithmXXHash64:
+		return "xxhash64"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(algorithm))
+}
+
+type KnownHashEvaluatorConfig struct {
+	Algorithm HashAlgorithm
+
+	// Exclude determines whether Files whose digests are known are dropped.  If Exclude is false, only Files whose
+	// digests are known are kept.
+	Exclude bool
+
+	// Hashes contains the known hex-encoded digests.  Case is ignored.
+	Hashes []string
+}
+
+//
+// Public constants
+//
+
+const (
+	HashAlgorithmMD5 HashAlgorithm = iota
+	HashAlgorithmSHA1
+	HashAlgorithmSHA256
+	HashAlgorithmXXHash64
+)
+
+//
+// Public functions
+//
+
+// Digest returns the hex-encoded digest of a File's contents.  If the digest has already been attached to the File
+// (e.g., because the File was read in full by a Source configured to compute it), it is returned as-is; otherwise the
+// File is read and the digest is attached to the File before being returned.
+func Digest(file File, algorithm HashAlgorithm) (string, error) {
+	var digest string
+	var err error
+	var hasher hash.Hash
+	var reader io.ReadCloser
+
+	if value, ok := file.Attributes().Get(algorithm.AttributeKey()); ok {
+		if digest, ok = value.(string); ok {
+			return digest, nil
+		}
+	}
+
+	if hasher, err = newHash(algorithm); err != nil {
+		return "", err
+	}
+
+	if reader, err = file.Reader(); err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(hasher, reader)
+
+	_ = reader.Close()
+
+	if err != nil {
+		return "", err
+	}
+
+	digest = hex.EncodeToString(hasher.Sum(nil))
+
+	file.Attributes().Set(algorithm.AttributeKey(), digest)
+
+	return digest, nil
+}
+
+// NewKnownHashEvaluator creates a FileEvaluator that keeps or drops Files based on whether their digests appear in a
+// list of known digests.
+func NewKnownHashEvaluator(config KnownHashEvaluatorConfig) (FileEvaluator, error) {
+	var evaluator = &knownHashEvaluator{
+		algorithm: config.Algorithm,
+		exclude:   config.Exclude,
+		hashes:    make(map[string]bool, len(config.Hashes)),
+	}
+
+	if _, err := newHash(config.Algorithm); err != nil {
+		return nil, err
+	}
+
+	for _, digest := range config.Hashes {
+		evaluator.hashes[strings.ToLower(digest)] = true
+	}
+
+	return evaluator, nil
+}
+
+//
+// Private types
+//
+
+// Computes digests for multiple HashAlgorithms at once as a File is read, attaching them to the File once it has been
+// read in full.
+type fileHasher struct {
+	attached bool
+	file     File
+	hashers  map[HashAlgorithm]hash.Hash
+	written  int64
+}
+
+// Attaches the digests to the File if the entire File has been hashed.  Digests are only attached once.
+func (hasher *fileHasher) attach(force bool) {
+	if hasher.attached || (!force && hasher.written != hasher.file.Size()) {
+		return
+	}
+
+	hasher.attached = true
+
+	for algorithm, h := range hasher.hashers {
+		hasher.file.Attributes().Set(algorithm.AttributeKey(), hex.EncodeToString(h.Sum(nil)))
+	}
+}
+
+func (hasher *fileHasher) write(p []byte) {
+	for _, h := range hasher.hashers {
+		// hash.Hash implementations never return errors.
+
+		_, _ = h.Write(p)
+	}
+
+	hasher.written += int64(len(p))
+}
+
+// FileEvaluator implementation that compares File digests against a list of known digests
+type knownHashEvaluator struct {
+	algorithm HashAlgorithm
+	exclude   bool
+	hashes    map[string]bool
+}
+
+func (evaluator *knownHashEvaluator) Destroy() error {
+	return nil
+}
+
+func (evaluator *knownHashEvaluator) ShouldKeep(file File) (bool, error) {
+	var digest string
+	var err error
+
+	if digest, err = Digest(file, evaluator.algorithm); err != nil {
+		return false, err
+	}
+
+	return evaluator.hashes[digest] != evaluator.exclude, nil
+}
+
+//
+// Private variables
+//
+
+var errHashAlgorithmUnknown = errors.New("unknown hash algorithm")
+
+//
+// Private functions
+//
+
+func newFileHasher(file File, algorithms []HashAlgorithm) *fileHasher {
+	var hasher = &fileHasher{
+		file:    file,
+		hashers: make(map[HashAlgorithm]hash.Hash, len(algorithms)),
+	}
+
+	for _, algorithm := range algorithms {
+		// Unknown algorithms are rejected when the Source is created.
+
+		hasher.hashers[algorithm], _ = newHash(algorithm)
+	}
+
+	return hasher
+}
+
+func newHash(algorithm HashAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case HashAlgorithmMD5:
+		return md5.New(), nil
+
+	case HashAlgorithmSHA1:
+		return sha1.New(), nil
+
+	case HashAlgorithmSHA256:
+		return sha256.New(), nil
+
+	case HashAlgorithmXXHash64:
+		return xxhash.New(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errHashAlgorithmUnknown, algorithm)
+}
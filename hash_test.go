@@ -0,0 +1,303 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// Digest tests
+
+var _ = g.Describe("Digest", func() {
+	g.Describe("calling Digest", func() {
+		g.Context("for a file that has not been read", func() {
+			g.It("should read the file and attach the digest", func() {
+				var digest string
+				var err error
+				var f = newHashTestFile(nil)
+
+				digest, err = Digest(f, HashAlgorithmSHA256)
+
+				Expect(err).To(BeNil())
+				Expect(digest).To(Equal(hashTestDigests[HashAlgorithmSHA256]))
+				Expect(attributeValue(f, HashAlgorithmSHA256.AttributeKey())).To(Equal(digest))
+			})
+		})
+
+		g.Context("for a file that already has a digest attached", func() {
+			g.It("should return the attached digest without reading the file", func() {
+				var f = newHashTestFile(nil)
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				f.Attributes().Set(HashAlgorithmMD5.AttributeKey(), "digest")
+
+				Expect(Digest(f, HashAlgorithmMD5)).To(Equal("digest"))
+			})
+		})
+
+		g.Context("for a file that cannot be read", func() {
+			g.It("should return an error", func() {
+				var err error
+				var f = newHashTestFile(nil)
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				_, err = Digest(f, HashAlgorithmMD5)
+
+				Expect(err).To(beAPathError("", OperationRead, "name", ErrorCategoryFatal))
+			})
+		})
+
+		g.Context("with an unknown HashAlgorithm", func() {
+			g.It("should return an error", func() {
+				var err error
+
+				_, err = Digest(newHashTestFile(nil), HashAlgorithm(-1))
+
+				Expect(err).To(MatchError("unknown hash algorithm: unknown(-1)"))
+			})
+		})
+	})
+})
+
+// File hashing tests
+
+var _ = g.Describe("File", func() {
+	g.Describe("given a new instance that computes digests", func() {
+		var f *file
+
+		g.BeforeEach(func() {
+			f = newHashTestFile([]HashAlgorithm{HashAlgorithmMD5, HashAlgorithmSHA1, HashAlgorithmSHA256,
+				HashAlgorithmXXHash64})
+		})
+
+		g.Describe("calling Reader and reading until EOF", func() {
+			g.It("should attach every digest", func() {
+				var reader, err = f.Reader()
+
+				Expect(err).To(BeNil())
+				Expect(ioutil.ReadAll(reader)).To(BeEquivalentTo("abc"))
+				Expect(reader.Close()).To(BeNil())
+
+				for algorithm, digest := range hashTestDigests {
+					Expect(attributeValue(f, algorithm.AttributeKey())).To(Equal(digest))
+				}
+			})
+		})
+
+		g.Describe("calling Reader and reading every byte without reaching EOF", func() {
+			g.It("should attach every digest when closed", func() {
+				var reader, err = f.Reader()
+
+				Expect(err).To(BeNil())
+
+				_, err = io.ReadFull(reader, make([]byte, 3))
+
+				Expect(err).To(BeNil())
+				Expect(reader.Close()).To(BeNil())
+				Expect(attributeValue(f, HashAlgorithmSHA1.AttributeKey())).To(Equal(
+					hashTestDigests[HashAlgorithmSHA1]))
+			})
+		})
+
+		g.Describe("calling Reader and reading part of the file", func() {
+			g.It("should not attach any digests", func() {
+				var reader, err = f.Reader()
+
+				Expect(err).To(BeNil())
+
+				_, err = io.ReadFull(reader, make([]byte, 2))
+
+				Expect(err).To(BeNil())
+				Expect(reader.Close()).To(BeNil())
+				Expect(f.Attributes().Keys()).To(BeEmpty())
+			})
+		})
+
+		g.Describe("calling Reader and reading from a Filesystem that reports a negative count on error", func() {
+			g.It("should return an error without panicking", func() {
+				var amount int
+				var reader io.ReadCloser
+				var err error
+
+				f.fs = &negativeReadMemFilesystem{
+					memFilesystem: f.fs.(*memFilesystem),
+				}
+
+				reader, err = f.Reader()
+
+				Expect(err).To(BeNil())
+
+				amount, err = reader.Read(make([]byte, 3))
+
+				Expect(amount).To(Equal(0))
+				Expect(err).To(MatchError(ContainSubstring("negativeRead")))
+				Expect(reader.Close()).To(BeNil())
+				Expect(f.Attributes().Keys()).To(BeEmpty())
+			})
+		})
+	})
+})
+
+// HashAlgorithm tests
+
+var _ = g.Describe("HashAlgorithm", func() {
+	g.Describe("calling AttributeKey", func() {
+		g.It("should return the correct key", func() {
+			Expect(HashAlgorithmMD5.AttributeKey()).To(Equal("hash.md5"))
+			Expect(HashAlgorithmSHA1.AttributeKey()).To(Equal("hash.sha1"))
+			Expect(HashAlgorithmSHA256.AttributeKey()).To(Equal("hash.sha256"))
+			Expect(HashAlgorithmXXHash64.AttributeKey()).To(Equal("hash.xxhash64"))
+		})
+	})
+})
+
+// Known hash FileEvaluator tests
+
+var _ = g.Describe("NewKnownHashEvaluator", func() {
+	g.Describe("calling NewKnownHashEvaluator", func() {
+		g.Context("with an unknown HashAlgorithm", func() {
+			g.It("should return an error", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewKnownHashEvaluator(KnownHashEvaluatorConfig{
+					Algorithm: HashAlgorithm(10),
+				})
+
+				Expect(err).To(MatchError("unknown hash algorithm: unknown(10)"))
+				Expect(evaluator).To(BeNil())
+			})
+		})
+
+		g.Context("with a list of known hashes to keep", func() {
+			g.It("should only keep files with known hashes", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewKnownHashEvaluator(KnownHashEvaluatorConfig{
+					Algorithm: HashAlgorithmXXHash64,
+					Hashes:    []string{"44BC2CF5AD770999"},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(evaluator.ShouldKeep(newHashTestFile(nil))).To(BeTrue())
+
+				evaluator, err = NewKnownHashEvaluator(KnownHashEvaluatorConfig{
+					Algorithm: HashAlgorithmXXHash64,
+					Hashes:    []string{"0000000000000000"},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(evaluator.ShouldKeep(newHashTestFile(nil))).To(BeFalse())
+				Expect(evaluator.Destroy()).To(BeNil())
+			})
+		})
+
+		g.Context("with a list of known hashes to exclude", func() {
+			g.It("should drop files with known hashes", func() {
+				var err error
+				var evaluator FileEvaluator
+
+				evaluator, err = NewKnownHashEvaluator(KnownHashEvaluatorConfig{
+					Algorithm: HashAlgorithmMD5,
+					Exclude:   true,
+					Hashes:    []string{hashTestDigests[HashAlgorithmMD5]},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(evaluator.ShouldKeep(newHashTestFile(nil))).To(BeFalse())
+			})
+		})
+
+		g.Context("for a file that cannot be read", func() {
+			g.It("should return an error", func() {
+				var err error
+				var evaluator FileEvaluator
+				var f = newHashTestFile(nil)
+
+				f.fs.(*memFilesystem).readFileError = errors.New("readFile")
+
+				evaluator, err = NewKnownHashEvaluator(KnownHashEvaluatorConfig{
+					Algorithm: HashAlgorithmMD5,
+				})
+
+				Expect(err).To(BeNil())
+
+				_, err = evaluator.ShouldKeep(f)
+
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// In-memory Filesystem implementation whose readers always fail with a negative count, as libsmbclient does.
+type negativeReadMemFilesystem struct {
+	*memFilesystem
+}
+
+func (fs *negativeReadMemFilesystem) ReadFile(path string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(&negativeReader{}), nil
+}
+
+// io.Reader implementation that always fails with a negative count.
+type negativeReader struct{}
+
+func (reader *negativeReader) Read(p []byte) (int, error) {
+	return -1, errors.New("negativeRead")
+}
+
+//
+// Private variables
+//
+
+// Digests of "abc".
+var hashTestDigests = map[HashAlgorithm]string{
+	HashAlgorithmMD5:      "900150983cd24fb0d6963f7d28e17f72",
+	HashAlgorithmSHA1:     "a9993e364706816aba3e25717850c26c9cd0d89d",
+	HashAlgorithmSHA256:   "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	HashAlgorithmXXHash64: "44bc2cf5ad770999",
+}
+
+//
+// Private functions
+//
+
+func attributeValue(f File, key string) interface{} {
+	var value, _ = f.Attributes().Get(key)
+
+	return value
+}
+
+func newHashTestFile(hashes []HashAlgorithm) *file {
+	return &file{
+		fileInfo: &nilFileInfo{
+			name: "name",
+			size: 3,
+		},
+		fs: &memFilesystem{
+			root: &memFilesystemNode{
+				children: map[string]*memFilesystemNode{
+					"name": {
+						contents: "abc",
+					},
+				},
+			},
+		},
+		hashes: hashes,
+		path:   newFilePath(nil, "name", "/"),
+	}
+}
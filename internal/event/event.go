@@ -1,8 +1,11 @@
 package event // import "golang.handcraftedbits.com/pipewerx/internal/event"
 
 import (
+	"encoding/json"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 //
@@ -14,6 +17,10 @@ type Event interface {
 
 	Data() map[string]interface{}
 
+	Sequence() uint64
+
+	Time() time.Time
+
 	Type() string
 }
 
@@ -26,10 +33,11 @@ type Sink interface {
 //
 
 const (
-	FieldError  = "error"
-	FieldFile   = "file"
-	FieldID     = "id"
-	FieldLength = "length"
+	FieldAttributes = "attributes"
+	FieldError      = "error"
+	FieldFile       = "file"
+	FieldID         = "id"
+	FieldLength     = "length"
 
 	TypeCancelled      = "cancelled"
 	TypeClosed         = "closed"
@@ -63,7 +71,7 @@ func IsAllowedFrom(component string) bool {
 
 	globalSink.mutex.RLock()
 
-	result = globalSink.allowedMap[component]
+	result = globalSink.isAllowedFrom(component)
 
 	globalSink.mutex.RUnlock()
 
@@ -71,28 +79,90 @@ func IsAllowedFrom(component string) bool {
 }
 
 func RegisterSink(sink Sink) {
+	RegisterSinkFrom(sink)
+}
+
+// RegisterSinkFrom registers a Sink that also receives events from the given components, even if they are not allowed
+// by AllowFrom.  Other Sinks are unaffected.  The returned function unregisters the Sink.
+func RegisterSinkFrom(sink Sink, components ...string) func() {
+	var child *childSink
+	var once sync.Once
+
 	if sink == nil {
-		return
+		return func() {}
+	}
+
+	child = &childSink{
+		retained: make(map[string]bool),
+		sink:     sink,
+	}
+
+	for _, component := range components {
+		if strings.TrimSpace(component) != "" {
+			child.retained[component] = true
+		}
 	}
 
 	globalSink.mutex.Lock()
 
-	globalSink.children = append(globalSink.children, sink)
+	globalSink.children = append(globalSink.children, child)
+
+	for component := range child.retained {
+		globalSink.retainedMap[component]++
+	}
 
 	globalSink.mutex.Unlock()
+
+	return func() {
+		once.Do(func() {
+			UnregisterSink(sink)
+		})
+	}
 }
 
 func Send(event Event) {
 	globalSink.Send(event)
 }
 
+// Unmarshal reconstructs an Event from its JSON representation, keeping the sequence number it was recorded with.
+func Unmarshal(data []byte) (Event, error) {
+	var result = &event{}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func UnregisterSink(sink Sink) {
+	globalSink.mutex.Lock()
+	defer globalSink.mutex.Unlock()
+
+	for i, child := range globalSink.children {
+		if child.sink == sink {
+			globalSink.children = append(globalSink.children[:i], globalSink.children[i+1:]...)
+
+			for component := range child.retained {
+				if globalSink.retainedMap[component]--; globalSink.retainedMap[component] <= 0 {
+					delete(globalSink.retainedMap, component)
+				}
+			}
+
+			return
+		}
+	}
+}
+
 func WithID(component, id, eventType string) Event {
 	return &event{
 		EventComponent: component,
 		EventData: map[string]interface{}{
 			FieldID: id,
 		},
-		EventType: eventType,
+		EventSequence: atomic.AddUint64(&sequence, 1),
+		EventTime:     time.Now(),
+		EventType:     eventType,
 	}
 }
 
@@ -100,11 +170,18 @@ func WithID(component, id, eventType string) Event {
 // Private types
 //
 
+// A registered Sink along with the components it receives events from regardless of AllowFrom.
+type childSink struct {
+	retained map[string]bool
+	sink     Sink
+}
+
 // Sink implementation that delegates to child Sinks.
 type delegatingSink struct {
-	allowedMap map[string]bool
-	children   []Sink
-	mutex      sync.RWMutex
+	allowedMap  map[string]bool
+	children    []*childSink
+	mutex       sync.RWMutex
+	retainedMap map[string]int
 }
 
 func (sink *delegatingSink) Send(event Event) {
@@ -118,10 +195,16 @@ func (sink *delegatingSink) Send(event Event) {
 	sink.sendInternal(event)
 }
 
+// Determines whether an event should be produced at all, i.e., whether it is allowed or at least one Sink retains its
+// component.  Must be called with the mutex held.
+func (sink *delegatingSink) isAllowedFrom(component string) bool {
+	return sink.allowedMap[component] || sink.retainedMap[component] > 0
+}
+
 func (sink *delegatingSink) sendInternal(event Event) {
 	for _, child := range sink.children {
-		if globalSink.allowedMap[event.Component()] {
-			child.Send(event)
+		if sink.allowedMap[event.Component()] || child.retained[event.Component()] {
+			child.sink.Send(event)
 		}
 	}
 }
@@ -130,6 +213,8 @@ func (sink *delegatingSink) sendInternal(event Event) {
 type event struct {
 	EventComponent string                 `json:"component"`
 	EventData      map[string]interface{} `json:"data"`
+	EventSequence  uint64                 `json:"sequence"`
+	EventTime      time.Time              `json:"time"`
 	EventType      string                 `json:"type"`
 }
 
@@ -141,6 +226,14 @@ func (e *event) Data() map[string]interface{} {
 	return e.EventData
 }
 
+func (e *event) Sequence() uint64 {
+	return e.EventSequence
+}
+
+func (e *event) Time() time.Time {
+	return e.EventTime
+}
+
 func (e *event) Type() string {
 	return e.EventType
 }
@@ -151,9 +244,13 @@ func (e *event) Type() string {
 
 var (
 	globalSink = &delegatingSink{
-		allowedMap: make(map[string]bool),
-		mutex:      sync.RWMutex{},
+		allowedMap:  make(map[string]bool),
+		mutex:       sync.RWMutex{},
+		retainedMap: make(map[string]int),
 	}
+
+	// Incremented atomically so that every Event receives a unique, monotonically increasing sequence number.
+	sequence uint64
 )
 
 //
@@ -1,9 +1,11 @@
 package event // import "golanhandcraftedbits.com/pipewerx/internal/event"
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -39,6 +41,19 @@ var _ = Describe("event", func() {
 			})
 		})
 
+		Describe("calling Sequence", func() {
+			It("should return a sequence number greater than that of any previous event", func() {
+				Expect(WithID(component, "source", TypeCreated).Sequence()).To(BeNumerically(">", event.Sequence()))
+			})
+		})
+
+		Describe("calling Time", func() {
+			It("should return the time at which the event was created", func() {
+				Expect(event.Time()).NotTo(BeZero())
+				Expect(event.Time()).To(BeTemporally("<=", time.Now()))
+			})
+		})
+
 		Describe("calling Type", func() {
 			It("should return the expected type", func() {
 				Expect(event.Type()).To(Equal(TypeCreated))
@@ -131,6 +146,148 @@ var _ = Describe("RegisterSink", func() {
 	})
 })
 
+var _ = Describe("RegisterSinkFrom", func() {
+	Describe("calling RegisterSinkFrom", func() {
+		Context("with a component that is not otherwise allowed", func() {
+			It("should only deliver events from the component to the Sink that retained it", func() {
+				var component = "registerSinkFromTest"
+				var other = newTestSink()
+				var retaining = newTestSink()
+				var unregister func()
+
+				AllowFrom(component, false)
+				RegisterSink(other)
+
+				defer UnregisterSink(other)
+
+				unregister = RegisterSinkFrom(retaining, component)
+
+				Expect(IsAllowedFrom(component)).To(BeTrue())
+
+				Send(WithID(component, retaining.id, TypeCreated))
+				Send(WithID(component, other.id, TypeCreated))
+
+				Expect(retaining).To(haveTheseEvents(component + "." + TypeCreated))
+				Expect(other.events).To(HaveLen(0))
+
+				unregister()
+				unregister()
+
+				Expect(IsAllowedFrom(component)).To(BeFalse())
+			})
+		})
+
+		Context("with a component retained by more than one Sink", func() {
+			It("should allow events until every Sink has been unregistered", func() {
+				var component = "registerSinkFromCountTest"
+				var unregisterFirst = RegisterSinkFrom(newTestSink(), component)
+				var unregisterSecond = RegisterSinkFrom(newTestSink(), component)
+
+				Expect(IsAllowedFrom(component)).To(BeTrue())
+
+				unregisterFirst()
+
+				Expect(IsAllowedFrom(component)).To(BeTrue())
+
+				unregisterSecond()
+
+				Expect(IsAllowedFrom(component)).To(BeFalse())
+			})
+		})
+
+		Context("with a component that is allowed", func() {
+			It("should leave the component allowed after the Sink is unregistered", func() {
+				var component = "registerSinkFromAllowedTest"
+
+				AllowFrom(component, true)
+
+				RegisterSinkFrom(newTestSink(), component)()
+
+				Expect(IsAllowedFrom(component)).To(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("Unmarshal", func() {
+	Describe("calling Unmarshal", func() {
+		Context("with invalid JSON", func() {
+			It("should return an error", func() {
+				var err error
+				var evt Event
+
+				evt, err = Unmarshal([]byte("{"))
+
+				Expect(evt).To(BeNil())
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		Context("with a marshalled Event", func() {
+			It("should reconstruct the Event", func() {
+				var data []byte
+				var err error
+				var evt Event
+				var original = WithID("unmarshalTest", "source", TypeCreated)
+
+				data, err = json.Marshal(original)
+
+				Expect(err).To(BeNil())
+
+				evt, err = Unmarshal(data)
+
+				Expect(err).To(BeNil())
+				Expect(evt.Component()).To(Equal(original.Component()))
+				Expect(evt.Data()).To(HaveKeyWithValue(FieldID, "source"))
+				Expect(evt.Sequence()).To(Equal(original.Sequence()))
+				Expect(evt.Time().Equal(original.Time())).To(BeTrue())
+				Expect(evt.Type()).To(Equal(original.Type()))
+			})
+		})
+	})
+})
+
+var _ = Describe("UnregisterSink", func() {
+	Describe("calling UnregisterSink", func() {
+		Context("with a registered Sink", func() {
+			It("should stop sending events to the Sink", func() {
+				var component = "unregisterTest"
+				var sink = newTestSink()
+
+				AllowFrom(component, true)
+				RegisterSink(sink)
+
+				Send(WithID(component, sink.id, TypeCreated))
+
+				UnregisterSink(sink)
+
+				Send(WithID(component, sink.id, TypeDestroyed))
+
+				Expect(sink).To(haveTheseEvents(component + "." + TypeCreated))
+			})
+		})
+
+		Context("with a Sink that was never registered", func() {
+			It("should be ignored", func() {
+				var size int
+
+				globalSink.mutex.RLock()
+
+				size = len(globalSink.children)
+
+				globalSink.mutex.RUnlock()
+
+				UnregisterSink(newTestSink())
+
+				globalSink.mutex.RLock()
+				defer globalSink.mutex.RUnlock()
+
+				Expect(len(globalSink.children)).To(Equal(size))
+			})
+		})
+	})
+})
+
 var _ = Describe("Send", func() {
 	Describe("calling Send", func() {
 		var sink *testSink
@@ -15,6 +15,7 @@ type fileInfo struct {
 	modTime time.Time
 	name    string
 	size    int64
+	sys     interface{}
 }
 
 func (fi *fileInfo) IsDir() bool {
@@ -38,5 +39,5 @@ func (fi *fileInfo) Size() int64 {
 }
 
 func (fi *fileInfo) Sys() interface{} {
-	return nil
+	return fi.sys
 }
@@ -200,6 +200,67 @@ func testFilesystem(config testFilesystemConfig) bool {
 				})
 			})
 
+			Describe("calling ReadFileSeekable", func() {
+				Context("when a valid file is specified", func() {
+					It("should allow the file to be read in any order", func() {
+						var amount int
+						var contents = make([]byte, 4)
+						var position int64
+						var reader pipewerx.SeekableReader
+						var seekable, ok = fs.(pipewerx.SeekableFilesystem)
+
+						Expect(ok).To(BeTrue())
+
+						reader, err = seekable.ReadFileSeekable(
+							config.realPath(testutil.TestdataPathFilesystem, "fileOnly.test"))
+
+						Expect(err).To(BeNil())
+						Expect(reader).NotTo(BeNil())
+
+						amount, err = reader.ReadAt(contents, 4)
+
+						Expect(err).To(BeNil())
+						Expect(amount).To(Equal(4))
+						Expect(string(contents)).To(Equal("Only"))
+
+						position, err = reader.Seek(0, io.SeekStart)
+
+						Expect(err).To(BeNil())
+						Expect(position).To(BeEquivalentTo(0))
+
+						amount, err = reader.Read(contents)
+
+						Expect(err).To(BeNil())
+						Expect(amount).To(Equal(4))
+						Expect(string(contents)).To(Equal("file"))
+
+						Expect(reader.Close()).To(BeNil())
+					})
+				})
+			})
+
+			Describe("calling WithRoot", func() {
+				It("should return a Filesystem that reads files relative to the new root", func() {
+					var contents []byte
+					var reader io.ReadCloser
+					var rooted, ok = fs.(pipewerx.RootedFilesystem)
+
+					Expect(ok).To(BeTrue())
+
+					reader, err = rooted.WithRoot(config.realPath(testutil.TestdataPathFilesystem, "filesOnly")).
+						ReadFile("a.test")
+
+					Expect(err).To(BeNil())
+					Expect(reader).NotTo(BeNil())
+
+					contents, err = ioutil.ReadAll(reader)
+
+					Expect(err).To(BeNil())
+					Expect(string(contents)).To(Equal("a"))
+					Expect(reader.Close()).To(BeNil())
+				})
+			})
+
 			Describe("calling StatFile", func() {
 				var fileInfo os.FileInfo
 
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/fsnotify/fsnotify"
+
 	"golang.handcraftedbits.com/pipewerx"
 )
 
@@ -62,6 +64,77 @@ func (fs *local) PathSeparator() string {
 }
 
 func (fs *local) ReadFile(path string) (io.ReadCloser, error) {
+	return fs.openFile(path)
+}
+
+func (fs *local) ReadFileSeekable(path string) (pipewerx.SeekableReader, error) {
+	return fs.openFile(path)
+}
+
+func (fs *local) StatFile(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Watches a path on the local filesystem.  fsnotify doesn't watch recursively, so every directory is watched
+// individually, including directories that are created after watching starts.
+func (fs *local) Watch(path string, recurse bool, stop <-chan struct{}) (<-chan struct{}, error) {
+	var changes = make(chan struct{}, 1)
+	var err error
+	var watcher *fsnotify.Watcher
+
+	if watcher, err = fsnotify.NewWatcher(); err != nil {
+		return nil, err
+	}
+
+	if err = addLocalWatches(watcher, path, recurse); err != nil {
+		_ = watcher.Close()
+
+		return nil, err
+	}
+
+	go func() {
+		defer close(changes)
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-stop:
+				return
+
+			case evt, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if recurse && evt.Op&fsnotify.Create != 0 {
+					// The new path might be a directory, and it might have been populated before it was watched.
+					// Either way the listing that follows the notification will find its contents.
+
+					_ = addLocalWatches(watcher, evt.Name, true)
+				}
+
+				notifyLocalWatch(changes)
+
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				// Errors usually mean that events were dropped, so report a change to make sure nothing is missed.
+
+				notifyLocalWatch(changes)
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+func (fs *local) WithRoot(root string) pipewerx.Filesystem {
+	return Local(root)
+}
+
+func (fs *local) openFile(path string) (*os.File, error) {
 	// If the base part of the filesystem root path is the same as the path, that implies that the root is a single file
 	// and we can't prepend the filesystem root to the path of the file that we're opening.
 
@@ -74,12 +147,39 @@ func (fs *local) ReadFile(path string) (io.ReadCloser, error) {
 	return os.Open(path)
 }
 
-func (fs *local) StatFile(path string) (os.FileInfo, error) {
-	return os.Stat(path)
-}
-
 //
 // Private constants
 //
 
 const localFSSeparator = string(os.PathSeparator)
+
+//
+// Private functions
+//
+
+func addLocalWatches(watcher *fsnotify.Watcher, path string, recurse bool) error {
+	if !recurse {
+		return watcher.Add(path)
+	}
+
+	return filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+
+		return nil
+	})
+}
+
+// Sends a notification unless one is already waiting to be received.
+func notifyLocalWatch(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+
+	default:
+	}
+}
@@ -1,3 +1,6 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
 package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
 
 /*
@@ -12,52 +15,48 @@ import (
 	"io"
 	"os"
 	pathutil "path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 	"unsafe"
 
 	"golang.handcraftedbits.com/pipewerx"
 )
 
-//
-// Public types
-//
-
-type SMBConfig struct {
-	Domain               string
-	EnableTestConditions bool
-	Host                 string
-	Password             string
-	Port                 int
-	Root                 string
-	Share                string
-	Username             string
-}
-
 //
 // Public functions
 //
 
 func SMB(config SMBConfig) (pipewerx.Filesystem, error) {
 	var cContext *C.SMBCCTX
-	var cDomain = C.CString(config.Domain)
-	var cPassword = C.CString(config.Password)
-	var cUsername = C.CString(config.Username)
 	var err error
+	var fs = &smb{
+		config: config,
+	}
+
+	if err = validateSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err = validateNativeSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	fs.pool = newSMBContextPool(&fs.config)
 
-	cContext, err = C.pipewerx_smb_create_context(cDomain, cUsername, cPassword, C.bool(config.EnableTestConditions))
+	// Create the first context up front so that configuration problems are reported right away.
 
-	if cContext == nil {
-		C.free(unsafe.Pointer(cDomain))
-		C.free(unsafe.Pointer(cPassword))
-		C.free(unsafe.Pointer(cUsername))
+	if cContext, err = fs.pool.get(); err != nil {
+		_ = fs.pool.destroy()
 
 		return nil, err
 	}
 
-	return &smb{
-		config:   config,
-		cContext: cContext,
-	}, nil
+	fs.pool.put(cContext)
+
+	return fs, nil
 }
 
 //
@@ -68,50 +67,49 @@ func SMB(config SMBConfig) (pipewerx.Filesystem, error) {
 type smb struct {
 	pipewerx.FilesystemDefaults
 
-	config   SMBConfig
-	cContext *C.SMBCCTX
+	config SMBConfig
+	pool   *smbContextPool
 }
 
 func (fs *smb) Destroy() error {
-	var err error
-	var cRet C.int
-
-	cRet, err = C.pipewerx_smb_destroy_context(fs.cContext, C.bool(fs.config.EnableTestConditions))
-
-	if int(cRet) != 0 {
-		return err
-	}
-
-	return nil
+	return fs.pool.destroy()
 }
 
 func (fs *smb) ListFiles(path string) ([]os.FileInfo, error) {
+	var cContext *C.SMBCCTX
 	var cDirHandle *C.SMBCFILE
-	var cURL = C.CString(fs.makeURL(path, false))
+	var url = fs.makeURL(path, false)
+	var cURL = C.CString(url)
 	var err error
 	var fileInfos = make([]os.FileInfo, 0)
 
 	defer C.free(unsafe.Pointer(cURL))
 
-	cDirHandle, err = C.pipewerx_smb_opendir(fs.cContext, cURL)
+	if cContext, err = fs.pool.get(); err != nil {
+		return nil, newSMBError("opendir", url, err)
+	}
+
+	defer fs.pool.put(cContext)
+
+	cDirHandle, err = C.pipewerx_smb_opendir(cContext, cURL)
 
 	if cDirHandle == nil {
-		return nil, err
+		return nil, newSMBError("opendir", url, err)
 	}
 
-	defer C.pipewerx_smb_closedir(fs.cContext, cDirHandle)
+	defer C.pipewerx_smb_closedir(cContext, cDirHandle)
 
 	for {
 		var cFileInfo *C.struct_libsmb_file_info
 		var cStat C.struct_stat
 		var name string
 
-		cFileInfo, err = C.pipewerx_smb_readdirplus2(fs.cContext, cDirHandle, &cStat,
+		cFileInfo, err = C.pipewerx_smb_readdirplus2(cContext, cDirHandle, &cStat,
 			C.bool(fs.config.EnableTestConditions))
 
 		if cFileInfo == nil {
 			if err != nil {
-				return nil, err
+				return nil, newSMBError("readdir", url, err)
 			}
 
 			// No error, so this is simply the end of the listing.
@@ -124,79 +122,243 @@ func (fs *smb) ListFiles(path string) ([]os.FileInfo, error) {
 		// libsmbclient returns "." and "..", which we don't want.  Filter them out.
 
 		if name != "." && name != ".." {
-			fileInfos = append(fileInfos, newSMBFileInfo(C.GoString(cFileInfo.name), &cStat))
+			var extended = newSMBExtendedFileInfo(cFileInfo)
+
+			if fs.config.SecurityDescriptors {
+				var entryURL = url + "/" + name
+
+				if extended.SecurityDescriptor, err = getSMBXattr(cContext, entryURL,
+					smbXattrSecurityDescriptor); err != nil {
+					return nil, newSMBError("getxattr", entryURL, err)
+				}
+			}
+
+			fileInfos = append(fileInfos, newSMBFileInfo(name, &cStat, extended))
 		}
 	}
 }
 
 func (fs *smb) ReadFile(path string) (io.ReadCloser, error) {
+	return fs.openFile(path)
+}
+
+func (fs *smb) ReadFileSeekable(path string) (pipewerx.SeekableReader, error) {
+	return fs.openFile(path)
+}
+
+func (fs *smb) StatFile(path string) (os.FileInfo, error) {
+	var cContext *C.SMBCCTX
+	var cRet C.int
+	var cStat C.struct_stat
+	var url = fs.makeURL(path, false)
+	var cURL = C.CString(url)
+	var err error
+	var extended *pipewerx.ExtendedFileInfo
+
+	defer C.free(unsafe.Pointer(cURL))
+
+	if cContext, err = fs.pool.get(); err != nil {
+		return nil, newSMBError("stat", url, err)
+	}
+
+	defer fs.pool.put(cContext)
+
+	cRet, err = C.pipewerx_smb_stat(cContext, cURL, &cStat)
+
+	if int(cRet) != 0 {
+		return nil, newSMBError("stat", url, err)
+	}
+
+	if extended, err = fs.statExtendedFileInfo(cContext, url, &cStat); err != nil {
+		return nil, newSMBError("getxattr", url, err)
+	}
+
+	return newSMBFileInfo(path, &cStat, extended), nil
+}
+
+// WithRoot returns a Filesystem that shares this one's contexts, so destroying either destroys both.
+func (fs *smb) WithRoot(root string) pipewerx.Filesystem {
+	var config = fs.config
+
+	config.Root = root
+
+	return &smb{
+		config: config,
+		pool:   fs.pool,
+	}
+}
+
+func (fs *smb) makeURL(path string, includeRoot bool) string {
+	if includeRoot && (fs.config.Root != "" && fs.config.Root != path) {
+		path = fs.config.Root + "/" + path
+	}
+
+	return fmt.Sprintf("smb://%s:%d/%s/%s", fs.config.Host, smbPort(&fs.config), fs.config.Share, pathutil.Clean(path))
+}
+
+// Opens a file for reading.  The returned reader holds on to a context until it is closed.
+func (fs *smb) openFile(path string) (*smbReadCloser, error) {
+	var cContext *C.SMBCCTX
 	var cFileHandle *C.SMBCFILE
-	var cURL = C.CString(fs.makeURL(path, true))
+	var url = fs.makeURL(path, true)
+	var cURL = C.CString(url)
 	var err error
 
 	defer C.free(unsafe.Pointer(cURL))
 
-	cFileHandle, err = C.pipewerx_smb_open(fs.cContext, cURL, C.int(os.O_RDONLY), C.mode_t(0))
+	if cContext, err = fs.pool.getForReader(); err != nil {
+		return nil, newSMBError("open", url, err)
+	}
+
+	cFileHandle, err = C.pipewerx_smb_open(cContext, cURL, C.int(os.O_RDONLY), C.mode_t(0))
 
 	if cFileHandle == nil {
-		return nil, err
+		fs.pool.putForReader(cContext)
+
+		return nil, newSMBError("open", url, err)
 	}
 
+	// The context is used by the reader until it is closed.
+
 	return &smbReadCloser{
-		cContext:    fs.cContext,
+		cContext:    cContext,
 		cFileHandle: cFileHandle,
+		pool:        fs.pool,
 	}, nil
 }
 
-func (fs *smb) StatFile(path string) (os.FileInfo, error) {
-	var cRet C.int
-	var cStat C.struct_stat
-	var cURL = C.CString(fs.makeURL(path, false))
+// Stat results don't include DOS attributes or the creation time, so they're retrieved using extended attributes.  Not
+// every server (or path, e.g., the root of a share) supports them, so they're left unset if they can't be retrieved.
+func (fs *smb) statExtendedFileInfo(cContext *C.SMBCCTX, url string, cStat *C.struct_stat) (*pipewerx.ExtendedFileInfo,
+	error) {
 	var err error
+	var extended = &pipewerx.ExtendedFileInfo{
+		AccessTime: timespecToTime(cStat.st_atim),
+		ChangeTime: timespecToTime(cStat.st_ctim),
+	}
 
-	defer C.free(unsafe.Pointer(cURL))
-
-	cRet, err = C.pipewerx_smb_stat(fs.cContext, cURL, &cStat)
+	if value, err := getSMBXattr(cContext, url, smbXattrDOSMode); err == nil {
+		if dosMode, err := strconv.ParseUint(strings.TrimSpace(value), 0, 32); err == nil {
+			extended.DOSAttributes = pipewerx.DOSAttributes(dosMode)
+		}
+	}
 
-	if int(cRet) != 0 {
-		return nil, err
+	if value, err := getSMBXattr(cContext, url, smbXattrCreateTime); err == nil {
+		if createTime, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			extended.CreationTime = time.Unix(createTime, 0)
+		}
 	}
 
-	return newSMBFileInfo(path, &cStat), nil
-}
+	// The security descriptor was explicitly requested, so failing to retrieve it is an error.
 
-func (fs *smb) makeURL(path string, includeRoot bool) string {
-	if includeRoot && (fs.config.Root != "" && fs.config.Root != path) {
-		path = fs.config.Root + "/" + path
+	if fs.config.SecurityDescriptors {
+		if extended.SecurityDescriptor, err = getSMBXattr(cContext, url, smbXattrSecurityDescriptor); err != nil {
+			return nil, err
+		}
 	}
 
-	return fmt.Sprintf("smb://%s:%d/%s/%s", fs.config.Host, fs.config.Port, fs.config.Share, pathutil.Clean(path))
+	return extended, nil
 }
 
-// SMB io.ReadCloser implementation
+// SMB pipewerx.SeekableReader implementation.  Calls are serialized, since the underlying context can't be used
+// concurrently.  Once the reader is closed its context belongs to the pool again, so it must not be used.
 type smbReadCloser struct {
 	cContext    *C.SMBCCTX
 	cFileHandle *C.SMBCFILE
+	closeErr    error
+	closed      bool
+	mutex       sync.Mutex
+	pool        *smbContextPool
 }
 
 func (reader *smbReadCloser) Close() error {
 	var cRet C.int
 	var err error
 
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	if reader.closed {
+		return reader.closeErr
+	}
+
+	reader.closed = true
+
 	cRet, err = C.pipewerx_smb_close(reader.cContext, reader.cFileHandle)
 
 	if int(cRet) != 0 {
-		return err
+		reader.closeErr = err
 	}
 
-	return nil
+	// Return the context even if closing failed, since the file handle is unusable either way.
+
+	reader.pool.putForReader(reader.cContext)
+
+	reader.cContext = nil
+	reader.cFileHandle = nil
+
+	return reader.closeErr
 }
 
 func (reader *smbReadCloser) Read(p []byte) (int, error) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	return reader.read(p)
+}
+
+// ReadAt reads from the given offset by temporarily moving the file offset, since libsmbclient doesn't provide a
+// positional read.
+func (reader *smbReadCloser) ReadAt(p []byte, offset int64) (int, error) {
+	var bytesRead int
+	var err error
+	var previous int64
+
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	if previous, err = reader.seek(0, io.SeekCurrent); err != nil {
+		return 0, err
+	}
+
+	if _, err = reader.seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	// Unlike Read, ReadAt must fill the buffer unless an error occurs.
+
+	for bytesRead < len(p) && err == nil {
+		var read int
+
+		if read, err = reader.read(p[bytesRead:]); read > 0 {
+			bytesRead += read
+		}
+	}
+
+	if _, seekErr := reader.seek(previous, io.SeekStart); err == nil {
+		err = seekErr
+	}
+
+	return bytesRead, err
+}
+
+func (reader *smbReadCloser) Seek(offset int64, whence int) (int64, error) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	return reader.seek(offset, whence)
+}
+
+// Must be called while holding the mutex.
+func (reader *smbReadCloser) read(p []byte) (int, error) {
 	var bytesRead int
 	var err error
 	var read C.ssize_t
 
+	if reader.closed {
+		return 0, os.ErrClosed
+	}
+
 	if len(p) == 0 {
 		return 0, nil
 	}
@@ -205,8 +367,14 @@ func (reader *smbReadCloser) Read(p []byte) (int, error) {
 
 	bytesRead = int(read)
 
+	// libsmbclient doesn't always set errno, and io.Reader doesn't allow a negative count.
+
 	if bytesRead < 0 {
-		return bytesRead, err
+		if err == nil {
+			err = syscall.EIO
+		}
+
+		return 0, err
 	} else if bytesRead > 0 {
 		return bytesRead, nil
 	}
@@ -214,11 +382,33 @@ func (reader *smbReadCloser) Read(p []byte) (int, error) {
 	return bytesRead, io.EOF
 }
 
+// Must be called while holding the mutex.
+func (reader *smbReadCloser) seek(offset int64, whence int) (int64, error) {
+	var err error
+	var position C.off_t
+
+	if reader.closed {
+		return 0, os.ErrClosed
+	}
+
+	position, err = C.pipewerx_smb_lseek(reader.cContext, reader.cFileHandle, C.off_t(offset), C.int(whence))
+
+	if int64(position) < 0 {
+		if err == nil {
+			err = syscall.EIO
+		}
+
+		return 0, err
+	}
+
+	return int64(position), nil
+}
+
 //
 // Private functions
 //
 
-func newSMBFileInfo(path string, cStat *C.struct_stat) os.FileInfo {
+func newSMBFileInfo(path string, cStat *C.struct_stat, extended *pipewerx.ExtendedFileInfo) os.FileInfo {
 	var mode = os.FileMode(cStat.st_mode)
 
 	// os.FileMode doesn't use the same directory mask as stat does, so if we find the stat directory mask
@@ -230,8 +420,9 @@ func newSMBFileInfo(path string, cStat *C.struct_stat) os.FileInfo {
 
 	return &fileInfo{
 		mode:    mode & (os.ModeDir | os.ModePerm),
-		modTime: time.Unix(int64(cStat.st_mtim.tv_sec), int64(cStat.st_mtim.tv_nsec)),
+		modTime: timespecToTime(cStat.st_mtim),
 		name:    path,
 		size:    int64(cStat.st_size),
+		sys:     extended,
 	}
 }
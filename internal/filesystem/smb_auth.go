@@ -0,0 +1,97 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+/*
+#include "smb_native.h"
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+//
+// Private variables
+//
+
+// Credential providers can't be handed to libsmbclient directly, so they're registered here and referred to by ID.
+var smbCredentialProviders = struct {
+	sync.RWMutex
+
+	nextID    uintptr
+	providers map[uintptr]SMBCredentialProvider
+}{
+	providers: make(map[uintptr]SMBCredentialProvider),
+}
+
+//
+// Private functions
+//
+
+// Called by libsmbclient (by way of pipewerx_smb_auth_func) when it needs credentials for a server.  Returns a non-zero
+// value if the credentials can't be retrieved, in which case authentication fails.
+//
+//export pipewerx_smb_get_credentials
+func pipewerx_smb_get_credentials(id C.uintptr_t, cServer, cShare, cDomain *C.char, domainLen C.int,
+	cUsername *C.char, usernameLen C.int, cPassword *C.char, passwordLen C.int) C.int {
+	var credentials SMBCredentials
+	var err error
+	var provider SMBCredentialProvider
+
+	smbCredentialProviders.RLock()
+	provider = smbCredentialProviders.providers[uintptr(id)]
+	smbCredentialProviders.RUnlock()
+
+	if provider == nil {
+		return 1
+	}
+
+	if credentials, err = provider(C.GoString(cServer), C.GoString(cShare)); err != nil {
+		return 1
+	}
+
+	if credentials.Domain != "" {
+		copyToCString(cDomain, domainLen, credentials.Domain)
+	}
+
+	copyToCString(cPassword, passwordLen, credentials.Password)
+	copyToCString(cUsername, usernameLen, credentials.Username)
+
+	return 0
+}
+
+// Copies a string into a fixed-size C buffer, truncating it if necessary.
+func copyToCString(cBuffer *C.char, length C.int, value string) {
+	var buffer []byte
+
+	if length <= 0 {
+		return
+	}
+
+	buffer = (*[1 << 30]byte)(unsafe.Pointer(cBuffer))[:length:length]
+
+	buffer[copy(buffer[:length-1], value)] = 0
+}
+
+func registerSMBCredentialProvider(provider SMBCredentialProvider) uintptr {
+	smbCredentialProviders.Lock()
+	defer smbCredentialProviders.Unlock()
+
+	// Zero means "no provider" to the native code.
+
+	smbCredentialProviders.nextID++
+
+	smbCredentialProviders.providers[smbCredentialProviders.nextID] = provider
+
+	return smbCredentialProviders.nextID
+}
+
+func unregisterSMBCredentialProvider(id uintptr) {
+	smbCredentialProviders.Lock()
+	defer smbCredentialProviders.Unlock()
+
+	delete(smbCredentialProviders.providers, id)
+}
@@ -0,0 +1,310 @@
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+//
+// Public types
+//
+
+// SMBAuth determines how an SMB Filesystem authenticates with a server.
+type SMBAuth int
+
+// SMBConfig configures an SMB Filesystem.  By default, SMB Filesystems use libsmbclient, but a pure-Go client is used
+// instead when building with the puresmb build tag or with cgo disabled.  Options that the pure-Go client doesn't
+// support cause SMB() to return an error.
+type SMBConfig struct {
+	// Auth determines how the SMB Filesystem authenticates with the server.  SMBAuthPassword is used if Auth is not
+	// set.  The pure-Go client doesn't support SMBAuthAnonymous or SMBAuthKerberos.
+	Auth SMBAuth
+
+	// Credentials, if set, is used to retrieve credentials whenever a connection is made, in which case Password and
+	// Username are ignored and Domain is only used if the SMBCredentials don't include one.  Credentials can only be
+	// used with SMBAuthPassword.
+	Credentials SMBCredentialProvider
+
+	// Domain, if set, is used as the workgroup when authenticating.
+	Domain               string
+	EnableTestConditions bool
+
+	// Encryption determines whether traffic is encrypted.  SMBEncryptionRequire can be used to make sure that data is
+	// never sent in the clear.  The pure-Go client only encrypts traffic when the server requires it, so it only
+	// supports SMBEncryptionDefault and SMBEncryptionNone.
+	Encryption SMBEncryption
+
+	Host string
+
+	// MaxConnections is the maximum number of libsmbclient contexts, and therefore connections, that readers can use
+	// at once.  Readers use a context until they are closed, so MaxConnections limits the number of files that can be
+	// read at once.  One more context is kept for listing and stat operations, which use a context for their duration,
+	// so that open readers don't keep them from running.  A default of 4 is used if MaxConnections is not set.  The
+	// pure-Go client multiplexes every operation over a single connection, so it ignores MaxConnections.
+	MaxConnections int
+
+	// MaxIdleTime is the amount of time after which an unused context is destroyed.  Unused contexts are kept until
+	// the Filesystem is destroyed if MaxIdleTime is not set.  The pure-Go client ignores MaxIdleTime.
+	MaxIdleTime time.Duration
+
+	// MaxProtocol and MinProtocol limit the versions of the SMB protocol that can be negotiated.  Note that
+	// libsmbclient applies these limits to the entire process, so every SMB Filesystem should use the same limits.
+	// The pure-Go client can only negotiate a single dialect when limits are set, so it uses SMB 2.1 for a maximum of
+	// SMBProtocolSMB2 and SMB 3.1.1 for a minimum of SMBProtocolSMB3.
+	MaxProtocol SMBProtocol
+	MinProtocol SMBProtocol
+
+	Password string
+
+	// Port is the port used to connect to the server.  If Port is not set, it is determined by Transport.
+	Port int
+
+	Root string
+
+	// SecurityDescriptors determines whether the security descriptor of each file is retrieved and included in its
+	// pipewerx.ExtendedFileInfo.  Doing so requires an extra request for each file.  The pure-Go client doesn't
+	// support SecurityDescriptors.
+	SecurityDescriptors bool
+
+	Share string
+
+	// Signing determines whether traffic is signed, which keeps it from being tampered with.  The pure-Go client
+	// always signs traffic when the server requires it, so it doesn't support SMBSigningOff.  libsmbclient doesn't
+	// provide a way to set signing for a context, so only SMBSigningDefault is supported when using it; use the
+	// "client signing" setting in smb.conf instead.
+	Signing SMBSigning
+
+	// Timeout is the amount of time to wait for the server to respond.  libsmbclient's default is used if Timeout is
+	// not set.  The pure-Go client only uses Timeout when connecting.
+	Timeout time.Duration
+
+	// Transport determines whether direct TCP or NetBIOS is used.  Transport is ignored if Port is set.  The pure-Go
+	// client doesn't support SMBTransportNetBIOS.
+	Transport SMBTransport
+
+	Username string
+}
+
+// SMBCredentialProvider retrieves the credentials used to authenticate with a given host and share.  It is called
+// whenever a new connection to a server is made, so credentials don't need to be held for the life of the process.
+type SMBCredentialProvider func(host, share string) (SMBCredentials, error)
+
+type SMBCredentials struct {
+	Domain   string
+	Password string
+	Username string
+}
+
+// SMBEncryption determines whether SMB traffic is encrypted.
+type SMBEncryption int
+
+// SMBProtocol identifies a version of the SMB protocol.
+type SMBProtocol int
+
+type SMBShare struct {
+	Comment string
+	Name    string
+	Type    SMBShareType
+}
+
+// SMBShareType identifies the kind of resource an SMBShare provides.
+type SMBShareType int
+
+func (shareType SMBShareType) String() string {
+	switch shareType {
+	case SMBShareTypeDisk:
+		return "disk"
+
+	case SMBShareTypePrinter:
+		return "printer"
+
+	case SMBShareTypeComms:
+		return "comms"
+
+	case SMBShareTypeIPC:
+		return "ipc"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(shareType))
+}
+
+// SMBSigning determines whether SMB traffic is signed.
+type SMBSigning int
+
+// SMBTransport determines how an SMB Filesystem connects to a server.
+type SMBTransport int
+
+//
+// Public constants
+//
+
+const (
+	// SMBAuthPassword authenticates using a username and password.  This is the default.
+	SMBAuthPassword SMBAuth = iota
+
+	// SMBAuthKerberos authenticates using the Kerberos credentials cache (e.g., the one named by the KRB5CCNAME
+	// environment variable).  Falling back to another method is not allowed.
+	SMBAuthKerberos
+
+	// SMBAuthAnonymous authenticates using an empty username and password.
+	SMBAuthAnonymous
+
+	// SMBAuthGuest authenticates using the guest account.
+	SMBAuthGuest
+)
+
+const (
+	// SMBEncryptionDefault uses the encryption setting from smb.conf.  This is the default.
+	SMBEncryptionDefault SMBEncryption = iota
+
+	// SMBEncryptionNone disables encryption.
+	SMBEncryptionNone
+
+	// SMBEncryptionRequest encrypts traffic if the server supports it.
+	SMBEncryptionRequest
+
+	// SMBEncryptionRequire fails to connect if the server doesn't support encryption.  Encrypted traffic is also
+	// signed.
+	SMBEncryptionRequire
+)
+
+const (
+	// SMBProtocolDefault uses the protocol limit from smb.conf.  This is the default.
+	SMBProtocolDefault SMBProtocol = iota
+
+	SMBProtocolSMB2
+	SMBProtocolSMB3
+)
+
+const (
+	SMBShareTypeDisk SMBShareType = iota
+	SMBShareTypePrinter
+	SMBShareTypeComms
+	SMBShareTypeIPC
+)
+
+const (
+	// SMBSigningDefault uses the client's own signing setting, which is "client signing" in smb.conf for libsmbclient.
+	// This is the default.
+	SMBSigningDefault SMBSigning = iota
+
+	// SMBSigningOff never signs traffic, so connecting fails if the server requires signing.
+	SMBSigningOff
+
+	// SMBSigningIfRequired signs traffic if the server requires it.
+	SMBSigningIfRequired
+
+	// SMBSigningRequired fails to connect if the server doesn't support signing.
+	SMBSigningRequired
+)
+
+const (
+	// SMBTransportAuto tries direct TCP and then falls back to NetBIOS.  This is the default.
+	SMBTransportAuto SMBTransport = iota
+
+	// SMBTransportDirect uses direct TCP (port 445).
+	SMBTransportDirect
+
+	// SMBTransportNetBIOS uses NetBIOS over TCP (port 139).
+	SMBTransportNetBIOS
+)
+
+//
+// Private constants
+//
+
+const (
+	smbPortDirect  = 445
+	smbPortNetBIOS = 139
+)
+
+//
+// Private variables
+//
+
+var (
+	errSMBAuthCredentials   = errors.New("a credential provider can only be used with password authentication")
+	errSMBAuthUnknown       = errors.New("unknown SMB authentication method")
+	errSMBDestroyed         = errors.New("SMB Filesystem has been destroyed")
+	errSMBEncryptionUnknown = errors.New("unknown SMB encryption setting")
+	errSMBProtocolRange     = errors.New("minimum SMB protocol is greater than maximum SMB protocol")
+	errSMBProtocolUnknown   = errors.New("unknown SMB protocol")
+	errSMBSigningUnknown    = errors.New("unknown SMB signing setting")
+	errSMBTransportUnknown  = errors.New("unknown SMB transport")
+)
+
+//
+// Private functions
+//
+
+// Wraps an error returned by an SMB client so that it records the operation and URL that caused it.  libsmbclient
+// doesn't always set errno, so fall back to EIO in that case rather than returning a nil error.
+func newSMBError(operation, url string, err error) error {
+	if err == nil {
+		err = syscall.EIO
+	}
+
+	return &os.PathError{
+		Op:   operation,
+		Path: url,
+		Err:  err,
+	}
+}
+
+// Determines the port used to connect, preferring SMBConfig.Port if set.  A port of zero lets libsmbclient choose.
+func smbPort(config *SMBConfig) int {
+	if config.Port != 0 {
+		return config.Port
+	}
+
+	switch config.Transport {
+	case SMBTransportDirect:
+		return smbPortDirect
+
+	case SMBTransportNetBIOS:
+		return smbPortNetBIOS
+	}
+
+	return 0
+}
+
+func validateSMBConfig(config *SMBConfig) error {
+	switch config.Auth {
+	case SMBAuthPassword:
+
+	case SMBAuthAnonymous, SMBAuthGuest, SMBAuthKerberos:
+		if config.Credentials != nil {
+			return errSMBAuthCredentials
+		}
+
+	default:
+		return fmt.Errorf("%w: %d", errSMBAuthUnknown, int(config.Auth))
+	}
+
+	if config.Encryption < SMBEncryptionDefault || config.Encryption > SMBEncryptionRequire {
+		return fmt.Errorf("%w: %d", errSMBEncryptionUnknown, int(config.Encryption))
+	}
+
+	for _, protocol := range []SMBProtocol{config.MaxProtocol, config.MinProtocol} {
+		if protocol < SMBProtocolDefault || protocol > SMBProtocolSMB3 {
+			return fmt.Errorf("%w: %d", errSMBProtocolUnknown, int(protocol))
+		}
+	}
+
+	if config.MaxProtocol != SMBProtocolDefault && config.MinProtocol > config.MaxProtocol {
+		return errSMBProtocolRange
+	}
+
+	if config.Signing < SMBSigningDefault || config.Signing > SMBSigningRequired {
+		return fmt.Errorf("%w: %d", errSMBSigningUnknown, int(config.Signing))
+	}
+
+	if config.Transport < SMBTransportAuto || config.Transport > SMBTransportNetBIOS {
+		return fmt.Errorf("%w: %d", errSMBTransportUnknown, int(config.Transport))
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx"
+	"golang.handcraftedbits.com/pipewerx/internal/testutil"
+)
+
+//
+// Testcases
+//
+
+// SMB tests (common to both SMB implementations)
+
+var _ = Describe("SMB", func() {
+	It("should return an error if the authentication method is unknown", func() {
+		var err error
+		var fs pipewerx.Filesystem
+
+		fs, err = SMB(SMBConfig{
+			Auth: SMBAuth(-1),
+		})
+
+		Expect(fs).To(BeNil())
+		Expect(errors.Is(err, errSMBAuthUnknown)).To(BeTrue())
+	})
+
+	It("should return an error if a credential provider is used without password authentication", func() {
+		var err error
+		var fs pipewerx.Filesystem
+
+		fs, err = SMB(SMBConfig{
+			Auth: SMBAuthGuest,
+			Credentials: func(host, share string) (SMBCredentials, error) {
+				return SMBCredentials{}, nil
+			},
+		})
+
+		Expect(fs).To(BeNil())
+		Expect(err).To(Equal(errSMBAuthCredentials))
+	})
+
+	It("should return an error if the minimum protocol is greater than the maximum protocol", func() {
+		var err error
+		var fs pipewerx.Filesystem
+
+		fs, err = SMB(SMBConfig{
+			MaxProtocol: SMBProtocolSMB2,
+			MinProtocol: SMBProtocolSMB3,
+		})
+
+		Expect(fs).To(BeNil())
+		Expect(err).To(Equal(errSMBProtocolRange))
+	})
+
+	It("should return an error if the encryption setting, protocol, signing setting, or transport is unknown", func() {
+		var err error
+
+		_, err = SMB(SMBConfig{
+			Encryption: SMBEncryption(-1),
+		})
+
+		Expect(errors.Is(err, errSMBEncryptionUnknown)).To(BeTrue())
+
+		_, err = SMB(SMBConfig{
+			MinProtocol: SMBProtocol(10),
+		})
+
+		Expect(errors.Is(err, errSMBProtocolUnknown)).To(BeTrue())
+
+		_, err = SMB(SMBConfig{
+			Signing: SMBSigning(10),
+		})
+
+		Expect(errors.Is(err, errSMBSigningUnknown)).To(BeTrue())
+
+		_, err = SMB(SMBConfig{
+			Transport: SMBTransport(10),
+		})
+
+		Expect(errors.Is(err, errSMBTransportUnknown)).To(BeTrue())
+	})
+})
+
+var _ = testFilesystem(testFilesystemConfig{
+	createFunc: func() (pipewerx.Filesystem, error) {
+		return SMB(newSMBConfig(portSamba))
+	},
+	name: "SMB",
+	realPath: func(root, path string) string {
+		return path
+	},
+})
+
+// SMBShares tests
+
+var _ = Describe("SMBShares", func() {
+	It("should list the shares on the host", func() {
+		var err error
+		var shares []SMBShare
+
+		shares, err = SMBShares(newSMBConfig(portSamba))
+
+		Expect(err).To(BeNil())
+
+		for _, share := range shares {
+			if share.Name == testutil.ConstSMBShare {
+				Expect(share.Type).To(Equal(SMBShareTypeDisk))
+
+				return
+			}
+		}
+
+		Fail("share not found")
+	})
+
+	It("should return an error if the host can't be reached", func() {
+		var err error
+		var shares []SMBShare
+
+		shares, err = SMBShares(newSMBConfig(1))
+
+		Expect(shares).To(BeNil())
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+// SMBShareType tests
+
+var _ = Describe("SMBShareType", func() {
+	Describe("calling String", func() {
+		It("should return the name of the share type", func() {
+			Expect(SMBShareTypeDisk.String()).To(Equal("disk"))
+			Expect(SMBShareTypeIPC.String()).To(Equal("ipc"))
+			Expect(SMBShareType(10).String()).To(Equal("unknown(10)"))
+		})
+	})
+})
+
+// smbPort tests
+
+var _ = Describe("smbPort", func() {
+	It("should prefer the configured port", func() {
+		Expect(smbPort(&SMBConfig{
+			Port:      1445,
+			Transport: SMBTransportNetBIOS,
+		})).To(Equal(1445))
+	})
+
+	It("should use the transport to determine the port if no port is configured", func() {
+		Expect(smbPort(&SMBConfig{})).To(Equal(0))
+		Expect(smbPort(&SMBConfig{Transport: SMBTransportDirect})).To(Equal(445))
+		Expect(smbPort(&SMBConfig{Transport: SMBTransportNetBIOS})).To(Equal(139))
+	})
+})
+
+//
+// Private functions
+//
+
+func newSMBConfig(port int) SMBConfig {
+	return SMBConfig{
+		Domain:   testutil.ConstSMBDomain,
+		Host:     "localhost",
+		Password: testutil.ConstSMBPassword,
+		Port:     port,
+		Share:    testutil.ConstSMBShare,
+		Username: testutil.ConstSMBUser,
+	}
+}
@@ -0,0 +1,80 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+/*
+#include "smb_native.h"
+*/
+import "C"
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Private constants
+//
+
+// The names of the extended attributes libsmbclient uses to expose DOS attributes and security descriptors.
+const (
+	smbXattrCreateTime         = "system.dos_attr.create_time"
+	smbXattrDOSMode            = "system.dos_attr.mode"
+	smbXattrSecurityDescriptor = "system.nt_sec_desc.*+"
+)
+
+const (
+	smbXattrInitialSize = 1024
+	smbXattrMaxSize     = 1024 * 1024
+)
+
+//
+// Private functions
+//
+
+// Retrieves the value of an extended attribute, growing the buffer as needed since the size of the value isn't known
+// ahead of time.
+func getSMBXattr(cContext *C.SMBCCTX, url, name string) (string, error) {
+	var cName = C.CString(name)
+	var cURL = C.CString(url)
+
+	defer C.free(unsafe.Pointer(cName))
+	defer C.free(unsafe.Pointer(cURL))
+
+	for size := smbXattrInitialSize; ; size *= 2 {
+		var buffer = make([]byte, size)
+		var cRet C.int
+		var err error
+
+		cRet, err = C.pipewerx_smb_getxattr(cContext, cURL, cName, unsafe.Pointer(&buffer[0]), C.size_t(size-1))
+
+		if int(cRet) >= 0 {
+			return C.GoString((*C.char)(unsafe.Pointer(&buffer[0]))), nil
+		}
+
+		if err != syscall.ERANGE || size >= smbXattrMaxSize {
+			if err == nil {
+				err = syscall.EIO
+			}
+
+			return "", err
+		}
+	}
+}
+
+func newSMBExtendedFileInfo(cFileInfo *C.struct_libsmb_file_info) *pipewerx.ExtendedFileInfo {
+	return &pipewerx.ExtendedFileInfo{
+		AccessTime:    timespecToTime(cFileInfo.atime_ts),
+		ChangeTime:    timespecToTime(cFileInfo.ctime_ts),
+		CreationTime:  timespecToTime(cFileInfo.btime_ts),
+		DOSAttributes: pipewerx.DOSAttributes(cFileInfo.attrs),
+	}
+}
+
+func timespecToTime(ts C.struct_timespec) time.Time {
+	return time.Unix(int64(ts.tv_sec), int64(ts.tv_nsec))
+}
@@ -1,12 +1,17 @@
+// +build cgo,!puresmb
+
 #include <errno.h>
 #include <string.h>
 
 #include "smb_native.h"
+#include "_cgo_export.h"
 
 /* Struct definitions */
 
 typedef struct user_data
 {
+     int auth;
+     uintptr_t credentials_id;
      char *domain;
      char *password;
      char *username;
@@ -14,13 +19,85 @@ typedef struct user_data
 
 /* Function implementations */
 
+static const char *protocol_name (int protocol)
+{
+     switch (protocol)
+     {
+          case PIPEWERX_SMB_PROTOCOL_SMB2:
+               return "SMB2";
+
+          case PIPEWERX_SMB_PROTOCOL_SMB3:
+               return "SMB3";
+     }
+
+     return NULL;
+}
+
+static void copy_string (char *dest, const char *src, int len)
+{
+     if (len <= 0)
+     {
+          return;
+     }
+
+     strncpy(dest, src, len - 1);
+
+     dest[len - 1] = '\0';
+}
+
 void pipewerx_smb_auth_func (SMBCCTX *c, const char *srv, const char *shr, char *wg, int wglen, char *un, int unlen,
      char *pw, int pwlen)
 {
      user_data *data = (user_data *) smbc_getOptionUserData(c);
 
-     strncpy(un, data->username, unlen - 1);
-     strncpy(pw, data->password, pwlen - 1);
+     /* Leave the default workgroup alone unless a domain was provided. */
+
+     if (data->domain[0] != '\0')
+     {
+          copy_string(wg, data->domain, wglen);
+     }
+
+     switch (data->auth)
+     {
+          case PIPEWERX_SMB_AUTH_ANONYMOUS:
+               copy_string(un, "", unlen);
+               copy_string(pw, "", pwlen);
+
+               break;
+
+          case PIPEWERX_SMB_AUTH_GUEST:
+               copy_string(un, "guest", unlen);
+               copy_string(pw, "", pwlen);
+
+               break;
+
+          case PIPEWERX_SMB_AUTH_KERBEROS:
+               /* The credentials cache is used, so only the principal's username (if any) is needed. */
+
+               if (data->username[0] != '\0')
+               {
+                    copy_string(un, data->username, unlen);
+               }
+
+               copy_string(pw, "", pwlen);
+
+               break;
+
+          default:
+               if (data->credentials_id == 0)
+               {
+                    copy_string(un, data->username, unlen);
+                    copy_string(pw, data->password, pwlen);
+               }
+               else if (pipewerx_smb_get_credentials(data->credentials_id, (char *) srv, (char *) shr, wg, wglen, un,
+                    unlen, pw, pwlen) != 0)
+               {
+                    /* Make sure authentication fails rather than using the default credentials. */
+
+                    copy_string(un, "", unlen);
+                    copy_string(pw, "", pwlen);
+               }
+     }
 }
 
 int pipewerx_smb_close (SMBCCTX *context, SMBCFILE *file)
@@ -33,7 +110,8 @@ int pipewerx_smb_closedir (SMBCCTX *context, SMBCFILE *dir)
      return smbc_getFunctionClosedir(context)(context, dir);
 }
 
-SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *password, bool enable_test_conditions)
+SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *password, pipewerx_smb_options *options,
+     bool enable_test_conditions)
 {
      SMBCCTX *context;
      user_data *data;
@@ -52,6 +130,57 @@ SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *passwo
           return NULL;
      }
 
+     if (options->auth == PIPEWERX_SMB_AUTH_KERBEROS)
+     {
+          smbc_setOptionUseKerberos(context, 1);
+          smbc_setOptionFallbackAfterKerberos(context, 0);
+          smbc_setOptionUseCCache(context, 1);
+     }
+
+     if (options->credentials_id != 0)
+     {
+          /* Don't fall back to an anonymous login if the credential provider fails. */
+
+          smbc_setOptionNoAutoAnonymousLogin(context, 1);
+     }
+
+     switch (options->encryption)
+     {
+          case PIPEWERX_SMB_ENCRYPTION_NONE:
+               smbc_setOptionSmbEncryptionLevel(context, SMBC_ENCRYPTLEVEL_NONE);
+
+               break;
+
+          case PIPEWERX_SMB_ENCRYPTION_REQUEST:
+               smbc_setOptionSmbEncryptionLevel(context, SMBC_ENCRYPTLEVEL_REQUEST);
+
+               break;
+
+          case PIPEWERX_SMB_ENCRYPTION_REQUIRE:
+               smbc_setOptionSmbEncryptionLevel(context, SMBC_ENCRYPTLEVEL_REQUIRE);
+
+               break;
+     }
+
+     if (options->min_protocol != PIPEWERX_SMB_PROTOCOL_DEFAULT ||
+          options->max_protocol != PIPEWERX_SMB_PROTOCOL_DEFAULT)
+     {
+          if (!smbc_setOptionProtocols(context, protocol_name(options->min_protocol),
+               protocol_name(options->max_protocol)))
+          {
+               smbc_free_context(context, 1);
+
+               errno = EINVAL;
+
+               return NULL;
+          }
+     }
+
+     if (options->timeout_ms > 0)
+     {
+          smbc_setTimeout(context, options->timeout_ms);
+     }
+
      if (!smbc_init_context(context))
      {
           smbc_free_context(context, 1);
@@ -68,6 +197,8 @@ SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *passwo
           return NULL;
      }
 
+     data->auth = options->auth;
+     data->credentials_id = options->credentials_id;
      data->domain = domain;
      data->password = password;
      data->username = username;
@@ -91,12 +222,29 @@ int pipewerx_smb_destroy_context (SMBCCTX *context, bool enable_test_conditions)
 
      data = (user_data *) smbc_getOptionUserData(context);
 
+     /* Keep the user data if the context can't be freed, since destroying the context may be retried. */
+
+     if (smbc_free_context(context, 1) != 0)
+     {
+          return 1;
+     }
+
      free(data->domain);
      free(data->password);
      free(data->username);
      free(data);
 
-     return smbc_free_context(context, 1);
+     return 0;
+}
+
+int pipewerx_smb_getxattr (SMBCCTX *context, char *url, char *name, void *value, size_t size)
+{
+     return smbc_getFunctionGetxattr(context)(context, url, name, value, size);
+}
+
+off_t pipewerx_smb_lseek (SMBCCTX *context, SMBCFILE *file, off_t offset, int whence)
+{
+     return smbc_getFunctionLseek(context)(context, file, offset, whence);
 }
 
 SMBCFILE *pipewerx_smb_open (SMBCCTX *context, const char *fname, int flags, mode_t mode)
@@ -114,6 +262,11 @@ ssize_t pipewerx_smb_read (SMBCCTX *context, SMBCFILE *file, void *buf, size_t c
      return smbc_getFunctionRead(context)(context, file, buf, count);
 }
 
+struct smbc_dirent *pipewerx_smb_readdir (SMBCCTX *context, SMBCFILE *dir)
+{
+     return smbc_getFunctionReaddir(context)(context, dir);
+}
+
 const struct libsmb_file_info *pipewerx_smb_readdirplus2 (SMBCCTX *context, SMBCFILE *dir, struct stat *st,
      bool enable_test_conditions)
 {
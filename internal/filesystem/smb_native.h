@@ -3,25 +3,67 @@
 
 #include <libsmbclient.h>
 #include <stdbool.h>
+#include <stdint.h>
 #include <stdlib.h>
 #include <sys/stat.h>
 
+/* Constant definitions */
+
+/* These must match the SMBAuth constants. */
+
+#define PIPEWERX_SMB_AUTH_PASSWORD 0
+#define PIPEWERX_SMB_AUTH_KERBEROS 1
+#define PIPEWERX_SMB_AUTH_ANONYMOUS 2
+#define PIPEWERX_SMB_AUTH_GUEST 3
+
+/* These must match the SMBEncryption constants. */
+
+#define PIPEWERX_SMB_ENCRYPTION_DEFAULT 0
+#define PIPEWERX_SMB_ENCRYPTION_NONE 1
+#define PIPEWERX_SMB_ENCRYPTION_REQUEST 2
+#define PIPEWERX_SMB_ENCRYPTION_REQUIRE 3
+
+/* These must match the SMBProtocol constants. */
+
+#define PIPEWERX_SMB_PROTOCOL_DEFAULT 0
+#define PIPEWERX_SMB_PROTOCOL_SMB2 1
+#define PIPEWERX_SMB_PROTOCOL_SMB3 2
+
+/* Struct definitions */
+
+typedef struct pipewerx_smb_options
+{
+     int auth;
+     uintptr_t credentials_id;
+     int encryption;
+     int max_protocol;
+     int min_protocol;
+     int timeout_ms;
+} pipewerx_smb_options;
+
 /* Function definitions */
 
 int pipewerx_smb_close (SMBCCTX *context, SMBCFILE *file);
 
 int pipewerx_smb_closedir (SMBCCTX *context, SMBCFILE *dir);
 
-SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *password, bool enable_test_conditions);
+SMBCCTX *pipewerx_smb_create_context (char *domain, char *username, char *password, pipewerx_smb_options *options,
+     bool enable_test_conditions);
 
 int pipewerx_smb_destroy_context (SMBCCTX *context, bool enable_test_conditions);
 
+int pipewerx_smb_getxattr (SMBCCTX *context, char *url, char *name, void *value, size_t size);
+
+off_t pipewerx_smb_lseek (SMBCCTX *context, SMBCFILE *file, off_t offset, int whence);
+
 SMBCFILE *pipewerx_smb_open (SMBCCTX *context, const char *fname, int flags, mode_t mode);
 
 SMBCFILE *pipewerx_smb_opendir (SMBCCTX *context, char *url);
 
 ssize_t pipewerx_smb_read (SMBCCTX *context, SMBCFILE *file, void *buf, size_t count);
 
+struct smbc_dirent *pipewerx_smb_readdir (SMBCCTX *context, SMBCFILE *dir);
+
 const struct libsmb_file_info *pipewerx_smb_readdirplus2 (SMBCCTX *context, SMBCFILE *dir, struct stat *st,
      bool enable_test_conditions);
 
@@ -0,0 +1,301 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+/*
+#cgo pkg-config: smbclient
+
+#include "smb_native.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+//
+// Private types
+//
+
+// smbContextPool manages a set of libsmbclient contexts.  libsmbclient contexts can't be used concurrently, so each
+// operation checks out a context for its exclusive use and returns it once it's done.  Readers can hold on to a context
+// for a long time, so they can only check out SMBConfig.MaxConnections contexts and one more is kept for everything
+// else.  That way, open readers never keep listing and stat operations from running.
+type smbContextPool struct {
+	closed        bool
+	cond          *sync.Cond
+	config        *SMBConfig
+	count         int
+	credentialsID uintptr
+	idle          []smbIdleContext
+	mutex         sync.Mutex
+	readers       int
+}
+
+// Returns a context that was checked out using checkOut().
+func (pool *smbContextPool) checkIn(cContext *C.SMBCCTX, reader bool) {
+	var now = time.Now()
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if reader {
+		pool.readers--
+	}
+
+	// Readers and other operations wait for different things, so wake everyone up and let them sort it out.
+
+	defer pool.cond.Broadcast()
+
+	if pool.closed {
+		_ = pool.destroyContext(cContext)
+
+		return
+	}
+
+	pool.idle = append(pool.idle, smbIdleContext{
+		cContext: cContext,
+		since:    now,
+	})
+
+	pool.reap(now)
+}
+
+// Checks out a context, creating a new one if none are idle and the maximum number of contexts hasn't been reached.
+// Otherwise, waits until a context is returned.
+func (pool *smbContextPool) checkOut(reader bool) (*C.SMBCCTX, error) {
+	var cContext *C.SMBCCTX
+	var err error
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for {
+		if pool.closed {
+			return nil, errSMBDestroyed
+		}
+
+		pool.reap(time.Now())
+
+		if reader && pool.readers >= pool.maxConnections() {
+			pool.cond.Wait()
+
+			continue
+		}
+
+		if len(pool.idle) > 0 {
+			// Use the most recently returned context, so that the least recently used contexts can be reaped.
+
+			cContext = pool.idle[len(pool.idle)-1].cContext
+			pool.idle = pool.idle[:len(pool.idle)-1]
+		} else if pool.count < pool.maxConnections()+1 {
+			// Creating a context doesn't involve any network activity, so it's fine to do it while holding the mutex.
+
+			if cContext, err = newSMBContext(pool.config, pool.credentialsID); err != nil {
+				return nil, err
+			}
+
+			pool.count++
+		} else {
+			pool.cond.Wait()
+
+			continue
+		}
+
+		if reader {
+			pool.readers++
+		}
+
+		return cContext, nil
+	}
+}
+
+// Destroys every idle context and prevents new contexts from being checked out.  Contexts that are still checked out
+// are destroyed when they're returned.  Contexts that can't be destroyed are kept so that destroy() can be retried.
+func (pool *smbContextPool) destroy() error {
+	var firstErr error
+	var remaining []smbIdleContext
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.closed = true
+
+	if pool.credentialsID != 0 {
+		unregisterSMBCredentialProvider(pool.credentialsID)
+
+		pool.credentialsID = 0
+	}
+
+	for _, idle := range pool.idle {
+		if err := pool.destroyContext(idle.cContext); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			remaining = append(remaining, idle)
+		}
+	}
+
+	pool.idle = remaining
+
+	// Wake up anyone waiting for a context so they can find out that the pool has been closed.
+
+	pool.cond.Broadcast()
+
+	return firstErr
+}
+
+// Must be called while holding the mutex.
+func (pool *smbContextPool) destroyContext(cContext *C.SMBCCTX) error {
+	var cRet C.int
+	var err error
+
+	cRet, err = C.pipewerx_smb_destroy_context(cContext, C.bool(pool.config.EnableTestConditions))
+
+	if int(cRet) != 0 {
+		return err
+	}
+
+	pool.count--
+
+	return nil
+}
+
+// Checks out a context for a short operation, e.g., listing or stat.
+func (pool *smbContextPool) get() (*C.SMBCCTX, error) {
+	return pool.checkOut(false)
+}
+
+// Checks out a context for a reader, which uses it until the reader is closed.  The context must be returned using
+// putForReader().
+func (pool *smbContextPool) getForReader() (*C.SMBCCTX, error) {
+	return pool.checkOut(true)
+}
+
+func (pool *smbContextPool) maxConnections() int {
+	if pool.config.MaxConnections <= 0 {
+		return defaultSMBMaxConnections
+	}
+
+	return pool.config.MaxConnections
+}
+
+// Returns a context that was checked out using get().
+func (pool *smbContextPool) put(cContext *C.SMBCCTX) {
+	pool.checkIn(cContext, false)
+}
+
+// Returns a context that was checked out using getForReader().
+func (pool *smbContextPool) putForReader(cContext *C.SMBCCTX) {
+	pool.checkIn(cContext, true)
+}
+
+// Destroys contexts that have been idle for longer than SMBConfig.MaxIdleTime.  Must be called while holding the mutex.
+func (pool *smbContextPool) reap(now time.Time) {
+	var kept []smbIdleContext
+
+	if pool.config.MaxIdleTime <= 0 {
+		return
+	}
+
+	for _, idle := range pool.idle {
+		if now.Sub(idle.since) < pool.config.MaxIdleTime || pool.destroyContext(idle.cContext) != nil {
+			kept = append(kept, idle)
+		}
+	}
+
+	pool.idle = kept
+}
+
+// A context that has been returned to an smbContextPool, along with the time it was returned.
+type smbIdleContext struct {
+	cContext *C.SMBCCTX
+	since    time.Time
+}
+
+//
+// Private constants
+//
+
+const defaultSMBMaxConnections = 4
+
+//
+// Private variables
+//
+
+var (
+	errSMBContext           = errors.New("unable to create SMB context")
+	errSMBNativeUnsupported = errors.New("option not supported by libsmbclient")
+)
+
+//
+// Private functions
+//
+
+// Creates a new libsmbclient context.  The context takes ownership of the credentials, which are freed when the
+// context is destroyed.  If a credential provider has been registered, its ID is used to retrieve credentials instead.
+func newSMBContext(config *SMBConfig, credentialsID uintptr) (*C.SMBCCTX, error) {
+	var cContext *C.SMBCCTX
+	var cDomain = C.CString(config.Domain)
+	var cOptions = newSMBOptions(config, credentialsID)
+	var cPassword = C.CString(config.Password)
+	var cUsername = C.CString(config.Username)
+	var err error
+
+	cContext, err = C.pipewerx_smb_create_context(cDomain, cUsername, cPassword, &cOptions,
+		C.bool(config.EnableTestConditions))
+
+	if cContext == nil {
+		C.free(unsafe.Pointer(cDomain))
+		C.free(unsafe.Pointer(cPassword))
+		C.free(unsafe.Pointer(cUsername))
+
+		if err == nil {
+			err = errSMBContext
+		}
+
+		return nil, err
+	}
+
+	return cContext, nil
+}
+
+// Collects the options that are applied when creating a context.
+func newSMBOptions(config *SMBConfig, credentialsID uintptr) C.pipewerx_smb_options {
+	return C.pipewerx_smb_options{
+		auth:           C.int(config.Auth),
+		credentials_id: C.uintptr_t(credentialsID),
+		encryption:     C.int(config.Encryption),
+		max_protocol:   C.int(config.MaxProtocol),
+		min_protocol:   C.int(config.MinProtocol),
+		timeout_ms:     C.int(config.Timeout / time.Millisecond),
+	}
+}
+
+func newSMBContextPool(config *SMBConfig) *smbContextPool {
+	var pool = &smbContextPool{
+		config: config,
+	}
+
+	pool.cond = sync.NewCond(&pool.mutex)
+
+	if config.Credentials != nil {
+		pool.credentialsID = registerSMBCredentialProvider(config.Credentials)
+	}
+
+	return pool
+}
+
+func validateNativeSMBConfig(config *SMBConfig) error {
+	if config.Signing != SMBSigningDefault {
+		return fmt.Errorf("%w: setting signing", errSMBNativeUnsupported)
+	}
+
+	return nil
+}
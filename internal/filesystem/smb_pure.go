@@ -0,0 +1,498 @@
+//go:build !cgo || puresmb
+// +build !cgo puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	pathutil "path"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/hirochachacha/go-smb2"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Public functions
+//
+
+func SMB(config SMBConfig) (pipewerx.Filesystem, error) {
+	var err error
+
+	if err = validateSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err = validatePureSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	// Connecting is deferred until the first operation, just like it is with libsmbclient.
+
+	return &smb{
+		config:     config,
+		connection: &pureSMBConnection{},
+	}, nil
+}
+
+// SMBServers lists the servers in the workgroup given by SMBConfig.Domain.  The pure-Go client can't browse
+// workgroups, so it always returns an error.
+func SMBServers(config SMBConfig) ([]string, error) {
+	return nil, fmt.Errorf("%w: listing servers", errSMBUnsupported)
+}
+
+// SMBShares lists the shares on the host given by SMBConfig.Host.  Only the authentication and connection settings in
+// the SMBConfig are used.  The pure-Go client can only retrieve share names, so every share other than IPC$ is reported
+// as SMBShareTypeDisk and comments are left empty.
+func SMBShares(config SMBConfig) ([]SMBShare, error) {
+	var err error
+	var names []string
+	var session *pureSMBSession
+	var shares = make([]SMBShare, 0)
+	var url = fmt.Sprintf("smb://%s:%d/", config.Host, smbPort(&config))
+
+	if err = validateSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err = validatePureSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if session, err = dialPureSMB(&config, ""); err != nil {
+		return nil, newSMBError("opendir", url, err)
+	}
+
+	defer session.close()
+
+	if names, err = session.session.ListSharenames(); err != nil {
+		return nil, newSMBError("opendir", url, translatePureSMBError(err))
+	}
+
+	for _, name := range names {
+		var shareType = SMBShareTypeDisk
+
+		if name == "IPC$" {
+			shareType = SMBShareTypeIPC
+		}
+
+		shares = append(shares, SMBShare{
+			Name: name,
+			Type: shareType,
+		})
+	}
+
+	return shares, nil
+}
+
+//
+// Private types
+//
+
+// The mounted share used by an smb and every Filesystem created from it using WithRoot().
+type pureSMBConnection struct {
+	destroyed bool
+	mutex     sync.Mutex
+	session   *pureSMBSession
+	share     *smb2.Share
+}
+
+// A connection to a server.
+type pureSMBSession struct {
+	conn    net.Conn
+	session *smb2.Session
+}
+
+func (session *pureSMBSession) close() error {
+	var err = session.session.Logoff()
+
+	if closeErr := session.conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// SMB pipewerx.Filesystem implementation that uses a pure-Go client.  The client multiplexes requests over a single
+// connection, so every operation shares the same session.
+type smb struct {
+	pipewerx.FilesystemDefaults
+
+	config     SMBConfig
+	connection *pureSMBConnection
+}
+
+func (fs *smb) Destroy() error {
+	var connection = fs.connection
+	var err error
+
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	connection.destroyed = true
+
+	if connection.share == nil {
+		return nil
+	}
+
+	err = connection.share.Umount()
+
+	if closeErr := connection.session.close(); err == nil {
+		err = closeErr
+	}
+
+	connection.session = nil
+	connection.share = nil
+
+	return err
+}
+
+func (fs *smb) ListFiles(path string) ([]os.FileInfo, error) {
+	var err error
+	var fileInfos []os.FileInfo
+	var result []os.FileInfo
+	var url = fs.makeURL(path, false)
+
+	if err = fs.withShare(func(share *smb2.Share) (err error) {
+		fileInfos, err = share.ReadDir(fs.sharePath(path, false))
+
+		return err
+	}); err != nil {
+		return nil, newSMBError("opendir", url, translatePureSMBError(err))
+	}
+
+	result = make([]os.FileInfo, 0, len(fileInfos))
+
+	for _, fileInfo := range fileInfos {
+		result = append(result, newPureSMBFileInfo(fileInfo.Name(), fileInfo))
+	}
+
+	return result, nil
+}
+
+func (fs *smb) ReadFile(path string) (io.ReadCloser, error) {
+	return fs.openFile(path)
+}
+
+func (fs *smb) ReadFileSeekable(path string) (pipewerx.SeekableReader, error) {
+	return fs.openFile(path)
+}
+
+func (fs *smb) StatFile(path string) (os.FileInfo, error) {
+	var err error
+	var fileInfo os.FileInfo
+	var url = fs.makeURL(path, false)
+
+	if err = fs.withShare(func(share *smb2.Share) (err error) {
+		fileInfo, err = share.Stat(fs.sharePath(path, false))
+
+		return err
+	}); err != nil {
+		return nil, newSMBError("stat", url, translatePureSMBError(err))
+	}
+
+	return newPureSMBFileInfo(path, fileInfo), nil
+}
+
+// WithRoot returns a Filesystem that shares this one's connection, so destroying either destroys both.
+func (fs *smb) WithRoot(root string) pipewerx.Filesystem {
+	var config = fs.config
+
+	config.Root = root
+
+	return &smb{
+		config:     config,
+		connection: fs.connection,
+	}
+}
+
+func (fs *smb) makeURL(path string, includeRoot bool) string {
+	return fmt.Sprintf("smb://%s:%d/%s/%s", fs.config.Host, smbPort(&fs.config), fs.config.Share,
+		fs.sharePath(path, includeRoot))
+}
+
+// Connects to the server and mounts the share if that hasn't already been done.
+func (fs *smb) mount() (*smb2.Share, error) {
+	var connection = fs.connection
+	var err error
+	var session *pureSMBSession
+	var share *smb2.Share
+
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	if connection.destroyed {
+		return nil, errSMBDestroyed
+	}
+
+	if connection.share != nil {
+		return connection.share, nil
+	}
+
+	if session, err = dialPureSMB(&fs.config, fs.config.Share); err != nil {
+		return nil, err
+	}
+
+	if share, err = session.session.Mount(fs.config.Share); err != nil {
+		_ = session.close()
+
+		return nil, translatePureSMBError(err)
+	}
+
+	connection.session = session
+	connection.share = share
+
+	return share, nil
+}
+
+func (fs *smb) openFile(path string) (*smb2.File, error) {
+	var err error
+	var file *smb2.File
+	var url = fs.makeURL(path, true)
+
+	if err = fs.withShare(func(share *smb2.Share) (err error) {
+		file, err = share.Open(fs.sharePath(path, true))
+
+		return err
+	}); err != nil {
+		return nil, newSMBError("open", url, translatePureSMBError(err))
+	}
+
+	return file, nil
+}
+
+// Converts a path to one that is relative to the share.  The pure-Go client uses "" rather than "." for the root of
+// the share.
+func (fs *smb) sharePath(path string, includeRoot bool) string {
+	if includeRoot && (fs.config.Root != "" && fs.config.Root != path) {
+		path = fs.config.Root + "/" + path
+	}
+
+	path = pathutil.Clean(path)
+
+	if path == "." {
+		return ""
+	}
+
+	return path
+}
+
+// Discards the given share, along with its connection, unless it has already been replaced.
+func (fs *smb) unmount(share *smb2.Share) {
+	var connection = fs.connection
+
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	if connection.share != share {
+		return
+	}
+
+	// The connection is already unusable, so there's no point in reporting errors when closing it.
+
+	_ = connection.session.close()
+
+	connection.session = nil
+	connection.share = nil
+}
+
+// Runs an operation using the mounted share.  Unlike libsmbclient, the pure-Go client doesn't reconnect on its own, so
+// if the connection has been lost (e.g., because the server was restarted) the share is mounted again using a new
+// connection and the operation is retried once.
+func (fs *smb) withShare(operation func(share *smb2.Share) error) error {
+	var err error
+	var share *smb2.Share
+
+	for retried := false; ; retried = true {
+		if share, err = fs.mount(); err != nil {
+			return err
+		}
+
+		if err = operation(share); err == nil || retried || !isPureSMBConnectionError(err) {
+			return err
+		}
+
+		fs.unmount(share)
+	}
+}
+
+//
+// Private constants
+//
+
+// The SMB dialects used when SMBConfig.MaxProtocol or SMBConfig.MinProtocol are set.
+const (
+	pureSMBDialectSMB2 = 0x0210
+	pureSMBDialectSMB3 = 0x0311
+)
+
+// The NTSTATUS codes that are translated into errno values so that pipewerx can categorize them.
+const (
+	ntStatusAccessDenied       = 0xC0000022
+	ntStatusNoSuchFile         = 0xC000000F
+	ntStatusObjectNameNotFound = 0xC0000034
+	ntStatusObjectPathNotFound = 0xC000003A
+)
+
+//
+// Private variables
+//
+
+var errSMBUnsupported = errors.New("option not supported by the pure-Go SMB client")
+
+//
+// Private functions
+//
+
+func dialPureSMB(config *SMBConfig, share string) (*pureSMBSession, error) {
+	var conn net.Conn
+	var credentials = SMBCredentials{
+		Domain:   config.Domain,
+		Password: config.Password,
+		Username: config.Username,
+	}
+	var dialer *smb2.Dialer
+	var err error
+	var port = smbPort(config)
+	var session *smb2.Session
+
+	if config.Credentials != nil {
+		var domain = credentials.Domain
+
+		if credentials, err = config.Credentials(config.Host, share); err != nil {
+			return nil, err
+		}
+
+		if credentials.Domain == "" {
+			credentials.Domain = domain
+		}
+	}
+
+	if config.Auth == SMBAuthGuest {
+		credentials.Password = ""
+		credentials.Username = "guest"
+	}
+
+	if port == 0 {
+		port = smbPortDirect
+	}
+
+	dialer = &smb2.Dialer{
+		Initiator: &smb2.NTLMInitiator{
+			Domain:   credentials.Domain,
+			Password: credentials.Password,
+			User:     credentials.Username,
+		},
+	}
+
+	if config.Signing == SMBSigningRequired {
+		dialer.Negotiator.RequireMessageSigning = true
+	}
+
+	switch {
+	case config.MinProtocol == SMBProtocolSMB3:
+		dialer.Negotiator.SpecifiedDialect = pureSMBDialectSMB3
+
+	case config.MaxProtocol == SMBProtocolSMB2:
+		dialer.Negotiator.SpecifiedDialect = pureSMBDialectSMB2
+	}
+
+	conn, err = net.DialTimeout("tcp", net.JoinHostPort(config.Host, strconv.Itoa(port)), config.Timeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if session, err = dialer.Dial(conn); err != nil {
+		_ = conn.Close()
+
+		return nil, translatePureSMBError(err)
+	}
+
+	return &pureSMBSession{
+		conn:    conn,
+		session: session,
+	}, nil
+}
+
+// Determines whether an error means that the connection to the server has been lost.  go-smb2 reports every error
+// from the underlying net.Conn as a TransportError once the connection is gone.
+func isPureSMBConnectionError(err error) bool {
+	var netErr net.Error
+	var transportErr *smb2.TransportError
+
+	return errors.As(err, &transportErr) || errors.As(err, &netErr) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func newPureSMBFileInfo(path string, source os.FileInfo) os.FileInfo {
+	var info = &fileInfo{
+		mode:    source.Mode() & (os.ModeDir | os.ModePerm),
+		modTime: source.ModTime(),
+		name:    path,
+		size:    source.Size(),
+	}
+
+	if stat, ok := source.(*smb2.FileStat); ok {
+		info.sys = &pipewerx.ExtendedFileInfo{
+			AccessTime:    stat.LastAccessTime,
+			ChangeTime:    stat.ChangeTime,
+			CreationTime:  stat.CreationTime,
+			DOSAttributes: pipewerx.DOSAttributes(stat.FileAttributes),
+		}
+	}
+
+	return info
+}
+
+// Replaces the NTSTATUS codes that pipewerx needs to categorize with the equivalent errno values, which is what
+// libsmbclient returns.  The errno values replace the client's own os.PathError, since the caller wraps them using
+// newSMBError().
+func translatePureSMBError(err error) error {
+	var responseErr *smb2.ResponseError
+
+	if !errors.As(err, &responseErr) {
+		return err
+	}
+
+	switch responseErr.Code {
+	case ntStatusAccessDenied:
+		return syscall.EACCES
+
+	case ntStatusNoSuchFile, ntStatusObjectNameNotFound, ntStatusObjectPathNotFound:
+		return syscall.ENOENT
+	}
+
+	return err
+}
+
+func validatePureSMBConfig(config *SMBConfig) error {
+	switch {
+	case config.Auth == SMBAuthAnonymous:
+		return fmt.Errorf("%w: anonymous authentication", errSMBUnsupported)
+
+	case config.Auth == SMBAuthKerberos:
+		return fmt.Errorf("%w: Kerberos authentication", errSMBUnsupported)
+
+	case config.Encryption == SMBEncryptionRequest || config.Encryption == SMBEncryptionRequire:
+		return fmt.Errorf("%w: requesting encryption", errSMBUnsupported)
+
+	case config.SecurityDescriptors:
+		return fmt.Errorf("%w: security descriptors", errSMBUnsupported)
+
+	case config.Signing == SMBSigningOff:
+		return fmt.Errorf("%w: turning signing off", errSMBUnsupported)
+
+	case config.Transport == SMBTransportNetBIOS:
+		return fmt.Errorf("%w: NetBIOS transport", errSMBUnsupported)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+//go:build !cgo || puresmb
+// +build !cgo puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/hirochachacha/go-smb2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Testcases
+//
+
+// Pure-Go SMB filesystem tests
+
+var _ = Describe("Pure-Go SMB Filesystem", func() {
+	Describe("calling SMB", func() {
+		It("should return an error if an unsupported option is used", func() {
+			for _, config := range []SMBConfig{
+				{Auth: SMBAuthAnonymous},
+				{Auth: SMBAuthKerberos},
+				{Encryption: SMBEncryptionRequire},
+				{SecurityDescriptors: true},
+				{Signing: SMBSigningOff},
+				{Transport: SMBTransportNetBIOS},
+			} {
+				var err error
+				var fs pipewerx.Filesystem
+
+				fs, err = SMB(config)
+
+				Expect(fs).To(BeNil())
+				Expect(errors.Is(err, errSMBUnsupported)).To(BeTrue())
+			}
+		})
+	})
+
+	Describe("given a new instance", func() {
+		var err error
+		var fs pipewerx.Filesystem
+
+		BeforeEach(func() {
+			fs, err = SMB(newSMBConfig(portSamba))
+
+			Expect(err).To(BeNil())
+			Expect(fs).NotTo(BeNil())
+		})
+
+		Describe("calling ListFiles", func() {
+			It("should include extended information for each file", func() {
+				var fileInfos []os.FileInfo
+
+				fileInfos, err = fs.ListFiles("filesOnly")
+
+				Expect(err).To(BeNil())
+				Expect(fileInfos).NotTo(BeEmpty())
+
+				for _, fileInfo := range fileInfos {
+					Expect(pipewerx.GetExtendedFileInfo(fileInfo)).NotTo(BeNil())
+				}
+
+				Expect(fs.Destroy()).To(BeNil())
+			})
+
+			It("should reconnect if the connection has been lost", func() {
+				var fileInfos []os.FileInfo
+				var smbFS = fs.(*smb)
+
+				_, err = fs.ListFiles("filesOnly")
+
+				Expect(err).To(BeNil())
+				Expect(smbFS.connection.session.conn.Close()).To(BeNil())
+
+				fileInfos, err = fs.ListFiles("filesOnly")
+
+				Expect(err).To(BeNil())
+				Expect(fileInfos).NotTo(BeEmpty())
+				Expect(fs.Destroy()).To(BeNil())
+			})
+
+			It("should return an error after the Filesystem has been destroyed", func() {
+				var fileInfos []os.FileInfo
+
+				Expect(fs.Destroy()).To(BeNil())
+
+				fileInfos, err = fs.ListFiles("filesOnly")
+
+				Expect(fileInfos).To(BeNil())
+				Expect(errors.Is(err, errSMBDestroyed)).To(BeTrue())
+			})
+		})
+
+		Describe("calling StatFile", func() {
+			It("should return an error that can be categorized if the file doesn't exist", func() {
+				_, err = fs.StatFile("missing")
+
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				Expect(fs.Destroy()).To(BeNil())
+			})
+		})
+	})
+})
+
+var _ = Describe("isPureSMBConnectionError", func() {
+	It("should only return true for errors caused by losing the connection", func() {
+		Expect(isPureSMBConnectionError(&smb2.TransportError{Err: io.EOF})).To(BeTrue())
+		Expect(isPureSMBConnectionError(&os.PathError{Err: &smb2.TransportError{Err: io.EOF}})).To(BeTrue())
+		Expect(isPureSMBConnectionError(io.ErrUnexpectedEOF)).To(BeTrue())
+		Expect(isPureSMBConnectionError(syscall.ENOENT)).To(BeFalse())
+		Expect(isPureSMBConnectionError(&smb2.ResponseError{Code: ntStatusAccessDenied})).To(BeFalse())
+	})
+})
+
+var _ = Describe("SMBServers", func() {
+	It("should return an error", func() {
+		var err error
+		var servers []string
+
+		servers, err = SMBServers(newSMBConfig(portSamba))
+
+		Expect(servers).To(BeNil())
+		Expect(errors.Is(err, errSMBUnsupported)).To(BeTrue())
+	})
+})
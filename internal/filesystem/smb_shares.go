@@ -0,0 +1,156 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
+package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
+
+/*
+#include "smb_native.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+//
+// Public functions
+//
+
+// SMBServers lists the servers in the workgroup given by SMBConfig.Domain.  Only the authentication and connection
+// settings in the SMBConfig are used.  Note that listing servers relies on the workgroup's master browser, which
+// isn't available on every network.
+func SMBServers(config SMBConfig) ([]string, error) {
+	var dirents []smbDirent
+	var err error
+	var servers = make([]string, 0)
+
+	if dirents, err = listSMBURL(config, fmt.Sprintf("smb://%s/", config.Domain)); err != nil {
+		return nil, err
+	}
+
+	for _, dirent := range dirents {
+		if dirent.smbcType == C.SMBC_SERVER {
+			servers = append(servers, dirent.name)
+		}
+	}
+
+	return servers, nil
+}
+
+// SMBShares lists the shares on the host given by SMBConfig.Host.  Only the authentication and connection settings in
+// the SMBConfig are used.
+func SMBShares(config SMBConfig) ([]SMBShare, error) {
+	var dirents []smbDirent
+	var err error
+	var shares = make([]SMBShare, 0)
+
+	if dirents, err = listSMBURL(config, fmt.Sprintf("smb://%s:%d/", config.Host, smbPort(&config))); err != nil {
+		return nil, err
+	}
+
+	for _, dirent := range dirents {
+		var shareType SMBShareType
+
+		switch dirent.smbcType {
+		case C.SMBC_FILE_SHARE:
+			shareType = SMBShareTypeDisk
+
+		case C.SMBC_PRINTER_SHARE:
+			shareType = SMBShareTypePrinter
+
+		case C.SMBC_COMMS_SHARE:
+			shareType = SMBShareTypeComms
+
+		case C.SMBC_IPC_SHARE:
+			shareType = SMBShareTypeIPC
+
+		default:
+			continue
+		}
+
+		shares = append(shares, SMBShare{
+			Comment: dirent.comment,
+			Name:    dirent.name,
+			Type:    shareType,
+		})
+	}
+
+	return shares, nil
+}
+
+//
+// Private types
+//
+
+// A copy of a libsmbclient directory entry.
+type smbDirent struct {
+	comment  string
+	name     string
+	smbcType C.uint
+}
+
+//
+// Private functions
+//
+
+// Lists the entries at a URL using a short-lived context.
+func listSMBURL(config SMBConfig, url string) ([]smbDirent, error) {
+	var cContext *C.SMBCCTX
+	var cDirHandle *C.SMBCFILE
+	var cURL = C.CString(url)
+	var dirents = make([]smbDirent, 0)
+	var err error
+	var pool *smbContextPool
+
+	if err = validateSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err = validateNativeSMBConfig(&config); err != nil {
+		return nil, err
+	}
+
+	defer C.free(unsafe.Pointer(cURL))
+
+	config.MaxConnections = 1
+
+	pool = newSMBContextPool(&config)
+
+	defer func() {
+		_ = pool.destroy()
+	}()
+
+	if cContext, err = pool.get(); err != nil {
+		return nil, newSMBError("opendir", url, err)
+	}
+
+	defer pool.put(cContext)
+
+	cDirHandle, err = C.pipewerx_smb_opendir(cContext, cURL)
+
+	if cDirHandle == nil {
+		return nil, newSMBError("opendir", url, err)
+	}
+
+	defer C.pipewerx_smb_closedir(cContext, cDirHandle)
+
+	for {
+		var cDirent *C.struct_smbc_dirent
+
+		cDirent = C.pipewerx_smb_readdir(cContext, cDirHandle)
+
+		if cDirent == nil {
+			// smbc_readdir doesn't reliably distinguish between the end of the listing and an error, so assume the
+			// former.
+
+			return dirents, nil
+		}
+
+		dirents = append(dirents, smbDirent{
+			comment:  C.GoString(cDirent.comment),
+			name:     C.GoString(&cDirent.name[0]),
+			smbcType: cDirent.smbc_type,
+		})
+	}
+}
@@ -1,8 +1,13 @@
+//go:build cgo && !puresmb
+// +build cgo,!puresmb
+
 package filesystem // import "golang.handcraftedbits.com/pipewerx/internal/filesystem"
 
 import (
+	"errors"
 	"io"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -30,9 +35,207 @@ var _ = Describe("SMB Filesystem", func() {
 			Expect(fs).To(BeNil())
 			Expect(err).NotTo(BeNil())
 		})
+
+		It("should return an error if signing is set, since libsmbclient doesn't support it", func() {
+			for _, signing := range []SMBSigning{SMBSigningOff, SMBSigningIfRequired, SMBSigningRequired} {
+				var err error
+				var fs pipewerx.Filesystem
+
+				fs, err = SMB(SMBConfig{
+					Signing: signing,
+				})
+
+				Expect(fs).To(BeNil())
+				Expect(errors.Is(err, errSMBNativeUnsupported)).To(BeTrue())
+			}
+		})
 	})
 
 	Describe("given a new instance", func() {
+		Context("with a credential provider", func() {
+			var err error
+			var fs pipewerx.Filesystem
+			var hosts []string
+			var smbFS *smb
+
+			BeforeEach(func() {
+				var config = newSMBConfig(portSamba)
+				var ok bool
+
+				hosts = nil
+
+				config.Credentials = func(host, share string) (SMBCredentials, error) {
+					hosts = append(hosts, host)
+
+					return SMBCredentials{
+						Domain:   testutil.ConstSMBDomain,
+						Password: testutil.ConstSMBPassword,
+						Username: testutil.ConstSMBUser,
+					}, nil
+				}
+				config.Password = ""
+				config.Username = ""
+
+				fs, err = SMB(config)
+
+				Expect(err).To(BeNil())
+				Expect(fs).NotTo(BeNil())
+
+				smbFS, ok = fs.(*smb)
+
+				Expect(ok).To(BeTrue())
+			})
+
+			Describe("calling ListFiles", func() {
+				It("should use the credentials returned by the provider", func() {
+					var fileInfos []os.FileInfo
+
+					fileInfos, err = fs.ListFiles("filesOnly")
+
+					Expect(err).To(BeNil())
+					Expect(fileInfos).NotTo(BeEmpty())
+					Expect(hosts).To(ContainElement("localhost"))
+
+					Expect(fs.Destroy()).To(BeNil())
+				})
+			})
+
+			Describe("calling Destroy", func() {
+				It("should unregister the credential provider", func() {
+					var id = smbFS.pool.credentialsID
+
+					Expect(id).NotTo(BeZero())
+					Expect(fs.Destroy()).To(BeNil())
+
+					smbCredentialProviders.RLock()
+
+					Expect(smbCredentialProviders.providers).NotTo(HaveKey(id))
+
+					smbCredentialProviders.RUnlock()
+				})
+			})
+		})
+
+		Context("with a maximum of two connections", func() {
+			var err error
+			var fs pipewerx.Filesystem
+
+			BeforeEach(func() {
+				var config = newSMBConfig(portSamba)
+
+				config.MaxConnections = 2
+
+				fs, err = SMB(config)
+
+				Expect(err).To(BeNil())
+				Expect(fs).NotTo(BeNil())
+			})
+
+			AfterEach(func() {
+				Expect(fs.Destroy()).To(BeNil())
+			})
+
+			Describe("calling ListFiles while two readers are open", func() {
+				It("should not wait for the readers to be closed", func() {
+					var fileInfos []os.FileInfo
+					var listed = make(chan struct{})
+					var readers []io.ReadCloser
+
+					fileInfos, err = fs.ListFiles("filesOnly")
+
+					Expect(err).To(BeNil())
+					Expect(fileInfos).NotTo(BeEmpty())
+
+					for i := 0; i < 2; i++ {
+						var reader io.ReadCloser
+
+						reader, err = fs.ReadFile("filesOnly/" + fileInfos[0].Name())
+
+						Expect(err).To(BeNil())
+
+						readers = append(readers, reader)
+					}
+
+					go func() {
+						defer GinkgoRecover()
+
+						_, err := fs.ListFiles("filesOnly")
+
+						Expect(err).To(BeNil())
+
+						_, err = fs.StatFile("filesOnly/" + fileInfos[0].Name())
+
+						Expect(err).To(BeNil())
+
+						close(listed)
+					}()
+
+					Eventually(listed).Should(BeClosed())
+
+					for _, reader := range readers {
+						Expect(reader.Close()).To(BeNil())
+					}
+				})
+			})
+		})
+
+		Context("with security descriptors enabled", func() {
+			var err error
+			var fs pipewerx.Filesystem
+
+			BeforeEach(func() {
+				var config = newSMBConfig(portSamba)
+
+				config.SecurityDescriptors = true
+
+				fs, err = SMB(config)
+
+				Expect(err).To(BeNil())
+				Expect(fs).NotTo(BeNil())
+			})
+
+			AfterEach(func() {
+				Expect(fs.Destroy()).To(BeNil())
+			})
+
+			Describe("calling ListFiles", func() {
+				It("should include extended information for each file", func() {
+					var fileInfos []os.FileInfo
+
+					fileInfos, err = fs.ListFiles("filesOnly")
+
+					Expect(err).To(BeNil())
+					Expect(fileInfos).NotTo(BeEmpty())
+
+					for _, fileInfo := range fileInfos {
+						var extended = pipewerx.GetExtendedFileInfo(fileInfo)
+
+						Expect(extended).NotTo(BeNil())
+						Expect(extended.CreationTime.IsZero()).To(BeFalse())
+						Expect(extended.SecurityDescriptor).To(HavePrefix("REVISION:"))
+					}
+				})
+			})
+
+			Describe("calling StatFile", func() {
+				It("should include extended information", func() {
+					var fileInfos []os.FileInfo
+					var fileInfo os.FileInfo
+
+					fileInfos, err = fs.ListFiles("filesOnly")
+
+					Expect(err).To(BeNil())
+					Expect(fileInfos).NotTo(BeEmpty())
+
+					fileInfo, err = fs.StatFile("filesOnly/" + fileInfos[0].Name())
+
+					Expect(err).To(BeNil())
+					Expect(pipewerx.GetExtendedFileInfo(fileInfo)).NotTo(BeNil())
+					Expect(pipewerx.GetExtendedFileInfo(fileInfo).SecurityDescriptor).To(HavePrefix("REVISION:"))
+				})
+			})
+		})
+
 		Context("with test conditions enabled", func() {
 			var err error
 			var fs pipewerx.Filesystem
@@ -83,14 +286,139 @@ var _ = Describe("SMB Filesystem", func() {
 	})
 })
 
-var _ = testFilesystem(testFilesystemConfig{
-	createFunc: func() (pipewerx.Filesystem, error) {
-		return SMB(newSMBConfig(portSamba))
-	},
-	name: "SMB",
-	realPath: func(root, path string) string {
-		return path
-	},
+// smbContextPool tests
+
+var _ = Describe("smbContextPool", func() {
+	Describe("given a new instance", func() {
+		var config SMBConfig
+		var pool *smbContextPool
+
+		JustBeforeEach(func() {
+			pool = newSMBContextPool(&config)
+		})
+
+		Context("with a maximum of one connection", func() {
+			BeforeEach(func() {
+				config = SMBConfig{
+					MaxConnections: 1,
+				}
+			})
+
+			AfterEach(func() {
+				Expect(pool.destroy()).To(BeNil())
+			})
+
+			Describe("calling get while a reader has checked out the only context", func() {
+				It("should use another context", func() {
+					first, err := pool.getForReader()
+
+					Expect(err).To(BeNil())
+
+					second, err := pool.get()
+
+					Expect(err).To(BeNil())
+					Expect(second).NotTo(Equal(first))
+
+					pool.put(second)
+					pool.putForReader(first)
+				})
+			})
+
+			Describe("calling getForReader while a reader has checked out the only context", func() {
+				It("should wait until the context is returned", func() {
+					var got = make(chan struct{})
+
+					first, err := pool.getForReader()
+
+					Expect(err).To(BeNil())
+
+					go func() {
+						defer GinkgoRecover()
+
+						second, err := pool.getForReader()
+
+						Expect(err).To(BeNil())
+						Expect(second).To(Equal(first))
+
+						pool.putForReader(second)
+
+						close(got)
+					}()
+
+					Consistently(got).ShouldNot(BeClosed())
+
+					pool.putForReader(first)
+
+					Eventually(got).Should(BeClosed())
+				})
+			})
+		})
+
+		Context("with a maximum idle time", func() {
+			BeforeEach(func() {
+				config = SMBConfig{
+					MaxIdleTime: time.Millisecond,
+				}
+			})
+
+			AfterEach(func() {
+				Expect(pool.destroy()).To(BeNil())
+			})
+
+			Describe("calling get after a context has been idle for too long", func() {
+				It("should destroy the idle context", func() {
+					cContext, err := pool.get()
+
+					Expect(err).To(BeNil())
+
+					pool.put(cContext)
+
+					Expect(pool.count).To(Equal(1))
+
+					time.Sleep(10 * time.Millisecond)
+
+					cContext, err = pool.get()
+
+					Expect(err).To(BeNil())
+					Expect(pool.count).To(Equal(1))
+					Expect(pool.idle).To(BeEmpty())
+
+					pool.put(cContext)
+				})
+			})
+		})
+
+		Context("that has been destroyed", func() {
+			BeforeEach(func() {
+				config = SMBConfig{}
+			})
+
+			Describe("calling get", func() {
+				It("should return an error", func() {
+					Expect(pool.destroy()).To(BeNil())
+
+					cContext, err := pool.get()
+
+					Expect(cContext).To(BeNil())
+					Expect(err).To(Equal(errSMBDestroyed))
+				})
+			})
+
+			Describe("calling put with a context that was checked out", func() {
+				It("should destroy the context", func() {
+					cContext, err := pool.get()
+
+					Expect(err).To(BeNil())
+					Expect(pool.destroy()).To(BeNil())
+					Expect(pool.count).To(Equal(1))
+
+					pool.put(cContext)
+
+					Expect(pool.count).To(Equal(0))
+				})
+			})
+		})
+	})
 })
 
 // smbReadCloser tests
@@ -115,9 +443,16 @@ var _ = Describe("smbReadCloser", func() {
 
 				Expect(ok).To(BeTrue())
 
+				// Test files can't use cgo, so the context's type has to be inferred.
+
+				cContext, err := smbFS.pool.getForReader()
+
+				Expect(err).To(BeNil())
+
 				reader = &smbReadCloser{
-					cContext:    smbFS.cContext,
+					cContext:    cContext,
 					cFileHandle: nil,
+					pool:        smbFS.pool,
 				}
 			})
 
@@ -131,6 +466,41 @@ var _ = Describe("smbReadCloser", func() {
 				It("should return an error", func() {
 					Expect(reader.Close()).NotTo(BeNil())
 				})
+
+				It("should return the same error and only return the context once when called again", func() {
+					var smbFS = fs.(*smb)
+
+					err = reader.Close()
+
+					Expect(err).NotTo(BeNil())
+					Expect(reader.Close()).To(Equal(err))
+					Expect(smbFS.pool.idle).To(HaveLen(1))
+				})
+			})
+
+			Describe("calling Read after calling Close", func() {
+				It("should return an error", func() {
+					var amountRead int
+
+					_ = reader.Close()
+
+					amountRead, err = reader.Read(make([]byte, 10))
+
+					Expect(amountRead).To(Equal(0))
+					Expect(err).To(Equal(os.ErrClosed))
+				})
+			})
+
+			Describe("calling Seek after calling Close", func() {
+				It("should return an error", func() {
+					var seekable = reader.(pipewerx.SeekableReader)
+
+					_ = reader.Close()
+
+					_, err = seekable.Seek(0, io.SeekStart)
+
+					Expect(err).To(Equal(os.ErrClosed))
+				})
 			})
 
 			Describe("calling Read", func() {
@@ -154,7 +524,7 @@ var _ = Describe("smbReadCloser", func() {
 					It("should return an error", func() {
 						amountRead, err = reader.Read(make([]byte, 10))
 
-						Expect(amountRead < 0).To(BeTrue())
+						Expect(amountRead).To(Equal(0))
 						Expect(err).NotTo(BeNil())
 					})
 				})
@@ -162,18 +532,3 @@ var _ = Describe("smbReadCloser", func() {
 		})
 	})
 })
-
-//
-// Private functions
-//
-
-func newSMBConfig(port int) SMBConfig {
-	return SMBConfig{
-		Domain:   testutil.ConstSMBDomain,
-		Host:     "localhost",
-		Password: testutil.ConstSMBPassword,
-		Port:     port,
-		Share:    testutil.ConstSMBShare,
-		Username: testutil.ConstSMBUser,
-	}
-}
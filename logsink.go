@@ -0,0 +1,129 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"golang.handcraftedbits.com/pipewerx/events"
+)
+
+//
+// Public types
+//
+
+// LogSink mirrors the events produced by files, Filters, and Sources into a Context's logger.
+type LogSink interface {
+	// Stop stops the LogSink from receiving events.
+	Stop()
+}
+
+// LogSinkConfig determines the level at which each kind of event is logged.  Every level defaults to
+// zerolog.DebugLevel; use zerolog.Disabled to prevent a kind of event from being logged.
+type LogSinkConfig struct {
+	// ErrorLevel is used for Results that contain an error.
+	ErrorLevel zerolog.Level
+
+	// FileLevel is used for file open, read, and close events.
+	FileLevel zerolog.Level
+
+	// LifecycleLevel is used for Filter and Source creation, start, cancellation, completion, and destruction events.
+	LifecycleLevel zerolog.Level
+
+	// ResultLevel is used for Results that contain a file.
+	ResultLevel zerolog.Level
+}
+
+//
+// Public functions
+//
+
+// NewLogSink creates a LogSink that starts logging events to Context.Log() immediately.
+func NewLogSink(context Context, config LogSinkConfig) LogSink {
+	var sink = &logSink{
+		config: config,
+		logger: context.Log(),
+	}
+
+	sink.unregister = events.RegisterFrom(events.ListenerFunc(sink.handle), componentFile, componentFilter,
+		componentSource)
+
+	return sink
+}
+
+//
+// Private types
+//
+
+// LogSink implementation
+type logSink struct {
+	config     LogSinkConfig
+	logger     *zerolog.Logger
+	once       sync.Once
+	unregister func()
+}
+
+func (sink *logSink) Stop() {
+	sink.once.Do(func() {
+		sink.unregister()
+	})
+}
+
+func (sink *logSink) handle(evt events.Event) {
+	var logEvent *zerolog.Event
+	var message string
+
+	switch typed := evt.(type) {
+	case events.FileClosed:
+		logEvent = sink.logger.WithLevel(sink.config.FileLevel).Str("path", typed.Path)
+		message = "file closed"
+
+	case events.FileOpened:
+		logEvent = sink.logger.WithLevel(sink.config.FileLevel).Str("path", typed.Path).Int64("size", typed.Size)
+		message = "file opened"
+
+	case events.FileRead:
+		logEvent = sink.logger.WithLevel(sink.config.FileLevel).Str("path", typed.Path).Int("bytes", typed.Bytes)
+		message = "file read"
+
+	case events.FilterCancelled, events.SourceCancelled:
+		logEvent = sink.logger.WithLevel(sink.config.LifecycleLevel)
+		message = evt.Component() + " cancelled"
+
+	case events.FilterCreated, events.SourceCreated:
+		logEvent = sink.logger.WithLevel(sink.config.LifecycleLevel)
+		message = evt.Component() + " created"
+
+	case events.FilterDestroyed, events.SourceDestroyed:
+		logEvent = sink.logger.WithLevel(sink.config.LifecycleLevel)
+		message = evt.Component() + " destroyed"
+
+	case events.FilterFinished, events.SourceFinished:
+		logEvent = sink.logger.WithLevel(sink.config.LifecycleLevel)
+		message = evt.Component() + " finished"
+
+	case events.FilterStarted, events.SourceStarted:
+		logEvent = sink.logger.WithLevel(sink.config.LifecycleLevel)
+		message = evt.Component() + " started"
+
+	case events.ResultProduced:
+		if typed.Error != "" {
+			logEvent = sink.logger.WithLevel(sink.config.ErrorLevel).Str("error", typed.Error)
+			message = evt.Component() + " produced an error"
+		} else {
+			logEvent = sink.logger.WithLevel(sink.config.ResultLevel).Str("path", typed.Path)
+			message = evt.Component() + " produced a file"
+		}
+
+	default:
+		return
+	}
+
+	// Events below the logger's level, or at zerolog.Disabled, result in a nil zerolog.Event which ignores any calls.
+
+	logEvent.
+		Str("component", evt.Component()).
+		Str("id", evt.ID()).
+		Uint64("sequence", evt.Sequence()).
+		Msg(message)
+}
@@ -0,0 +1,118 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"fmt"
+	"math/rand"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// LogSink tests
+
+var _ = g.Describe("LogSink", func() {
+	g.Describe("given a new instance", func() {
+		var buffer *syncBuffer
+		var config LogSinkConfig
+		var id string
+		var level zerolog.Level
+		var sink LogSink
+
+		g.BeforeEach(func() {
+			buffer = &syncBuffer{}
+			config = LogSinkConfig{}
+			id = fmt.Sprintf("%d", rand.Int())
+			level = zerolog.DebugLevel
+		})
+
+		g.JustBeforeEach(func() {
+			var f = &file{
+				fileInfo: &nilFileInfo{
+					name: "name",
+					size: 30,
+				},
+				path: newFilePath(nil, "name", "/"),
+			}
+
+			sink = NewLogSink(NewContext(ContextConfig{
+				Level:   level,
+				UseJSON: true,
+				Writer:  buffer,
+			}), config)
+
+			event.Send(sourceEventStarted(id))
+			event.Send(sourceEventResultProduced(id, &result{file: f}))
+			event.Send(sourceEventResultProduced(id, &result{err: fmt.Errorf("failure")}))
+			event.Send(fileEventOpened(f, id))
+			event.Send(fileEventRead(f, id, 10))
+			event.Send(fileEventClosed(f, id))
+			event.Send(filterEventFinished(id))
+		})
+
+		g.AfterEach(func() {
+			sink.Stop()
+		})
+
+		g.Context("which uses the default configuration", func() {
+			g.It("should log every event", func() {
+				var output = buffer.String()
+
+				Expect(output).To(ContainSubstring("source started"))
+				Expect(output).To(ContainSubstring("source produced a file"))
+				Expect(output).To(ContainSubstring("source produced an error"))
+				Expect(output).To(ContainSubstring(`"error":"failure"`))
+				Expect(output).To(ContainSubstring("file opened"))
+				Expect(output).To(ContainSubstring(`"size":30`))
+				Expect(output).To(ContainSubstring("file read"))
+				Expect(output).To(ContainSubstring(`"bytes":10`))
+				Expect(output).To(ContainSubstring("file closed"))
+				Expect(output).To(ContainSubstring("filter finished"))
+				Expect(output).To(ContainSubstring(`"id":"` + id + `"`))
+				Expect(output).To(ContainSubstring(`"path":"name"`))
+			})
+		})
+
+		g.Context("which uses custom levels", func() {
+			g.BeforeEach(func() {
+				config = LogSinkConfig{
+					ErrorLevel:     zerolog.WarnLevel,
+					FileLevel:      zerolog.Disabled,
+					LifecycleLevel: zerolog.DebugLevel,
+					ResultLevel:    zerolog.InfoLevel,
+				}
+				level = zerolog.InfoLevel
+			})
+
+			g.It("should only log events at or above the Context's level", func() {
+				var output = buffer.String()
+
+				Expect(output).NotTo(ContainSubstring("source started"))
+				Expect(output).NotTo(ContainSubstring("filter finished"))
+				Expect(output).NotTo(ContainSubstring("file "))
+				Expect(output).To(ContainSubstring("source produced a file"))
+				Expect(output).To(ContainSubstring("source produced an error"))
+			})
+		})
+
+		g.Describe("calling Stop", func() {
+			g.It("should stop logging events", func() {
+				var length int
+
+				sink.Stop()
+
+				length = len(buffer.String())
+
+				event.Send(sourceEventStarted(id))
+
+				Expect(buffer.String()).To(HaveLen(length))
+			})
+		})
+	})
+})
@@ -141,6 +141,43 @@ func (matcher *matcherBeAValidFileEvent) NegatedFailureMessage(actual interface{
 	return ""
 }
 
+// types.GomegaMatcher implementation used to ensure that an error is a PathError with the expected properties.
+type matcherBeAPathError struct {
+	category  ErrorCategory
+	operation string
+	path      string
+	sourceID  string
+}
+
+func (matcher *matcherBeAPathError) Match(actual interface{}) (bool, error) {
+	var err error
+	var ok bool
+	var pathErr PathError
+
+	err, ok = actual.(error)
+
+	if !ok {
+		return false, errors.New("beAPathError expects error")
+	}
+
+	Expect(errors.As(err, &pathErr)).To(BeTrue())
+	Expect(pathErr.Category()).To(Equal(matcher.category))
+	Expect(pathErr.Operation()).To(Equal(matcher.operation))
+	Expect(pathErr.Path()).To(Equal(matcher.path))
+	Expect(pathErr.SourceID()).To(Equal(matcher.sourceID))
+	Expect(pathErr.Unwrap()).NotTo(BeNil())
+
+	return true, nil
+}
+
+func (matcher *matcherBeAPathError) FailureMessage(actual interface{}) string {
+	return ""
+}
+
+func (matcher *matcherBeAPathError) NegatedFailureMessage(actual interface{}) string {
+	return ""
+}
+
 // types.GomegaMatcher implementation used to ensure that an array of Result objects contains all or some FilePaths from
 // a given set.
 type matcherHaveAllOrSomeOfTheseFilePaths struct {
@@ -223,6 +260,15 @@ func (matcher *matcherHaveTheseEvents) NegatedFailureMessage(actual interface{})
 // Private functions
 //
 
+func beAPathError(sourceID, operation, path string, category ErrorCategory) types.GomegaMatcher {
+	return &matcherBeAPathError{
+		category:  category,
+		operation: operation,
+		path:      path,
+		sourceID:  sourceID,
+	}
+}
+
 func beAValidEvent(component, eventType, id string) types.GomegaMatcher {
 	return &matcherBeAValidEvent{
 		component: component,
@@ -264,3 +310,15 @@ func haveTheseEvents(events ...string) types.GomegaMatcher {
 		events: events,
 	}
 }
+
+func resultFilePaths(results []Result) []string {
+	var paths []string
+
+	for _, res := range results {
+		if res.File() != nil {
+			paths = append(paths, res.File().Path().String())
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1 @@
+package metrics // import "golang.handcraftedbits.com/pipewerx/metrics"
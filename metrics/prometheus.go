@@ -0,0 +1,212 @@
+package metrics // import "golang.handcraftedbits.com/pipewerx/metrics"
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"golang.handcraftedbits.com/pipewerx/events"
+)
+
+//
+// Public types
+//
+
+// PrometheusSink translates events into Prometheus metrics labelled by component and ID.
+type PrometheusSink interface {
+	// Handler returns an http.Handler that can be used to scrape the metrics.
+	Handler() http.Handler
+
+	// Stop stops the PrometheusSink from receiving events.  Metrics that have already been collected remain available.
+	Stop()
+}
+
+type PrometheusSinkConfig struct {
+	// Buckets are the histogram buckets used for durations.  prometheus.DefBuckets is used if no buckets are provided.
+	Buckets []float64
+
+	// Namespace is prepended to every metric name.  "pipewerx" is used if no namespace is provided.
+	Namespace string
+
+	// Registry is the registry that metrics are registered with and that Handler() serves.  A new registry is created
+	// if none is provided.
+	Registry *prometheus.Registry
+}
+
+//
+// Public functions
+//
+
+// NewPrometheusSink creates a PrometheusSink that starts receiving events immediately.
+func NewPrometheusSink(config PrometheusSinkConfig) (PrometheusSink, error) {
+	var err error
+	var labels = []string{labelComponent, labelID}
+	var sink *prometheusSink
+
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	if config.Namespace == "" {
+		config.Namespace = defaultNamespace
+	}
+
+	if config.Registry == nil {
+		config.Registry = prometheus.NewRegistry()
+	}
+
+	sink = &prometheusSink{
+		bytesRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "file_bytes_read_total",
+			Help:      "Number of bytes read from files, labelled by the ID of the Source that produced them.",
+		}, []string{labelID}),
+		cancellations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "cancellations_total",
+			Help:      "Number of times a component was cancelled.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "errors_total",
+			Help:      "Number of error Results produced.",
+		}, labels),
+		fileReadDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "file_read_duration_seconds",
+			Help:      "Time between opening and closing a file, labelled by the ID of the Source that produced it.",
+			Buckets:   config.Buckets,
+		}, []string{labelID}),
+		filesProduced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "files_produced_total",
+			Help:      "Number of file Results produced.",
+		}, labels),
+		opened:   make(map[string][]time.Time),
+		registry: config.Registry,
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "stage_duration_seconds",
+			Help:      "Time between a component starting and finishing.",
+			Buckets:   config.Buckets,
+		}, labels),
+		started: make(map[string][]time.Time),
+	}
+
+	for _, collector := range []prometheus.Collector{sink.bytesRead, sink.cancellations, sink.errors,
+		sink.fileReadDuration, sink.filesProduced, sink.stageDuration} {
+		if err = config.Registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	sink.unregister = events.RegisterFrom(sink, events.ComponentFile, events.ComponentFilter, events.ComponentSource)
+
+	return sink, nil
+}
+
+//
+// Private constants
+//
+
+const (
+	defaultNamespace = "pipewerx"
+
+	labelComponent = "component"
+	labelID        = "id"
+)
+
+//
+// Private types
+//
+
+// PrometheusSink implementation
+type prometheusSink struct {
+	bytesRead        *prometheus.CounterVec
+	cancellations    *prometheus.CounterVec
+	errors           *prometheus.CounterVec
+	fileReadDuration *prometheus.HistogramVec
+	filesProduced    *prometheus.CounterVec
+	mutex            sync.Mutex
+	once             sync.Once
+	opened           map[string][]time.Time
+	registry         *prometheus.Registry
+	stageDuration    *prometheus.HistogramVec
+	started          map[string][]time.Time
+	unregister       func()
+}
+
+func (sink *prometheusSink) Handle(evt events.Event) {
+	switch typed := evt.(type) {
+	case events.FileClosed:
+		if start, ok := sink.popTime(sink.opened, typed.ID()+"/"+typed.Path); ok {
+			sink.fileReadDuration.WithLabelValues(typed.ID()).Observe(typed.Time().Sub(start).Seconds())
+		}
+
+	case events.FileOpened:
+		sink.pushTime(sink.opened, typed.ID()+"/"+typed.Path, typed.Time())
+
+	case events.FileRead:
+		sink.bytesRead.WithLabelValues(typed.ID()).Add(float64(typed.Bytes))
+
+	case events.FilterCancelled, events.SourceCancelled:
+		sink.cancellations.WithLabelValues(evt.Component(), evt.ID()).Inc()
+
+	case events.FilterFinished, events.SourceFinished:
+		if start, ok := sink.popTime(sink.started, evt.Component()+"/"+evt.ID()); ok {
+			sink.stageDuration.WithLabelValues(evt.Component(), evt.ID()).Observe(evt.Time().Sub(start).Seconds())
+		}
+
+	case events.FilterStarted, events.SourceStarted:
+		sink.pushTime(sink.started, evt.Component()+"/"+evt.ID(), evt.Time())
+
+	case events.ResultProduced:
+		if typed.Error != "" {
+			sink.errors.WithLabelValues(typed.Component(), typed.ID()).Inc()
+		} else {
+			sink.filesProduced.WithLabelValues(typed.Component(), typed.ID()).Inc()
+		}
+	}
+}
+
+func (sink *prometheusSink) Handler() http.Handler {
+	return promhttp.HandlerFor(sink.registry, promhttp.HandlerOpts{})
+}
+
+func (sink *prometheusSink) Stop() {
+	sink.once.Do(sink.unregister)
+}
+
+// Retrieves the oldest start time recorded for a key.  Multiple start times can be recorded for the same key since
+// the same component (or file) can be in use more than once at the same time.
+func (sink *prometheusSink) popTime(times map[string][]time.Time, key string) (time.Time, bool) {
+	var result time.Time
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if len(times[key]) == 0 {
+		return result, false
+	}
+
+	result = times[key][0]
+
+	if len(times[key]) == 1 {
+		delete(times, key)
+	} else {
+		times[key] = times[key][1:]
+	}
+
+	return result, true
+}
+
+func (sink *prometheusSink) pushTime(times map[string][]time.Time, key string, value time.Time) {
+	sink.mutex.Lock()
+
+	times[key] = append(times[key], value)
+
+	sink.mutex.Unlock()
+}
@@ -0,0 +1,163 @@
+package metrics // import "golang.handcraftedbits.com/pipewerx/metrics"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"golang.handcraftedbits.com/pipewerx/events"
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// PrometheusSink tests
+
+var _ = Describe("PrometheusSink", func() {
+	Describe("calling NewPrometheusSink", func() {
+		Context("with a registry that already contains the metrics", func() {
+			It("should return an error", func() {
+				var err error
+				var registry = prometheus.NewRegistry()
+				var sink PrometheusSink
+
+				sink, err = NewPrometheusSink(PrometheusSinkConfig{
+					Registry: registry,
+				})
+
+				Expect(err).To(BeNil())
+				Expect(sink).NotTo(BeNil())
+
+				sink.Stop()
+
+				sink, err = NewPrometheusSink(PrometheusSinkConfig{
+					Registry: registry,
+				})
+
+				Expect(err).NotTo(BeNil())
+				Expect(sink).To(BeNil())
+			})
+		})
+	})
+
+	Describe("given a new instance", func() {
+		var id string
+		var impl *prometheusSink
+		var sink PrometheusSink
+
+		BeforeEach(func() {
+			var err error
+
+			id = fmt.Sprintf("%d", rand.Int())
+
+			sink, err = NewPrometheusSink(PrometheusSinkConfig{})
+
+			Expect(err).To(BeNil())
+			Expect(sink).NotTo(BeNil())
+
+			impl = sink.(*prometheusSink)
+		})
+
+		AfterEach(func() {
+			sink.Stop()
+		})
+
+		Context("which has received events", func() {
+			BeforeEach(func() {
+				var evt event.Event
+
+				event.Send(event.WithID(events.ComponentSource, id, event.TypeStarted))
+
+				evt = event.WithID(events.ComponentSource, id, event.TypeResultProduced)
+				evt.Data()[event.FieldFile] = "a"
+
+				event.Send(evt)
+
+				evt = event.WithID(events.ComponentSource, id, event.TypeResultProduced)
+				evt.Data()[event.FieldError] = "error"
+
+				event.Send(evt)
+
+				evt = event.WithID(events.ComponentFile, id, event.TypeOpened)
+				evt.Data()[event.FieldFile] = "a"
+				evt.Data()[event.FieldLength] = int64(10)
+
+				event.Send(evt)
+
+				evt = event.WithID(events.ComponentFile, id, event.TypeRead)
+				evt.Data()[event.FieldFile] = "a"
+				evt.Data()[event.FieldLength] = 10
+
+				event.Send(evt)
+
+				evt = event.WithID(events.ComponentFile, id, event.TypeClosed)
+				evt.Data()[event.FieldFile] = "a"
+
+				event.Send(evt)
+
+				event.Send(event.WithID(events.ComponentFilter, id, event.TypeCancelled))
+				event.Send(event.WithID(events.ComponentSource, id, event.TypeFinished))
+			})
+
+			It("should have updated the metrics", func() {
+				Expect(testutil.ToFloat64(impl.bytesRead.WithLabelValues(id))).To(BeEquivalentTo(10))
+				Expect(testutil.ToFloat64(impl.cancellations.WithLabelValues(events.ComponentFilter,
+					id))).To(BeEquivalentTo(1))
+				Expect(testutil.ToFloat64(impl.errors.WithLabelValues(events.ComponentSource,
+					id))).To(BeEquivalentTo(1))
+				Expect(testutil.ToFloat64(impl.filesProduced.WithLabelValues(events.ComponentSource,
+					id))).To(BeEquivalentTo(1))
+
+				Expect(impl.opened).To(BeEmpty())
+				Expect(impl.started).To(BeEmpty())
+			})
+
+			Describe("calling Handler", func() {
+				It("should serve the metrics", func() {
+					var body []byte
+					var err error
+					var recorder = httptest.NewRecorder()
+
+					sink.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+					body, err = ioutil.ReadAll(recorder.Body)
+
+					Expect(err).To(BeNil())
+					Expect(string(body)).To(ContainSubstring(fmt.Sprintf(
+						`pipewerx_file_bytes_read_total{id="%s"} 10`, id)))
+					Expect(string(body)).To(ContainSubstring(fmt.Sprintf(
+						`pipewerx_stage_duration_seconds_count{component="source",id="%s"} 1`, id)))
+					Expect(string(body)).To(ContainSubstring(fmt.Sprintf(
+						`pipewerx_file_read_duration_seconds_count{id="%s"} 1`, id)))
+				})
+			})
+		})
+
+		Describe("calling Stop", func() {
+			It("should stop receiving events", func() {
+				sink.Stop()
+
+				event.Send(event.WithID(events.ComponentFilter, id, event.TypeCancelled))
+
+				Expect(testutil.ToFloat64(impl.cancellations.WithLabelValues(events.ComponentFilter,
+					id))).To(BeEquivalentTo(0))
+			})
+
+			It("should no longer allow events that were only allowed by the PrometheusSink", func() {
+				Expect(event.IsAllowedFrom(events.ComponentFile)).To(BeTrue())
+
+				sink.Stop()
+
+				Expect(event.IsAllowedFrom(events.ComponentFile)).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,18 @@
+package metrics // import "golang.handcraftedbits.com/pipewerx/metrics"
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+func TestSuiteMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "metrics")
+}
@@ -0,0 +1,225 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"golang.handcraftedbits.com/pipewerx/events"
+)
+
+//
+// Public types
+//
+
+// Progress is a snapshot of the progress made by a single Source, as determined by the events it produces.
+type Progress struct {
+	// BytesExpected is the total size of every file that has been opened.
+	BytesExpected int64
+
+	BytesRead int64
+
+	// ETA is the estimated amount of time it will take to finish reading every file that has been opened, based on the
+	// current throughput.  It is zero if the throughput is not yet known.
+	ETA time.Duration
+
+	Elapsed time.Duration
+
+	FilesClosed int
+
+	FilesOpened int
+
+	// FilesSeen is the number of files that the Source has produced.
+	FilesSeen int
+
+	SourceID string
+
+	// Throughput is the average number of bytes read per second since the Source produced its first event.
+	Throughput float64
+}
+
+// ProgressTracker aggregates file and Source events into per-Source Progress.
+type ProgressTracker interface {
+	// All returns the current Progress of every Source that has produced events, sorted by Source ID.
+	All() []Progress
+
+	// Progress returns the current Progress of a single Source.
+	Progress(sourceID string) (Progress, bool)
+
+	// Stop stops the ProgressTracker from receiving events and logging progress.
+	Stop()
+}
+
+type ProgressTrackerConfig struct {
+	// LogInterval determines how often progress is logged using Context.Log().  Progress is not logged if LogInterval
+	// is zero.
+	LogInterval time.Duration
+}
+
+//
+// Public functions
+//
+
+// NewProgressTracker creates a ProgressTracker that starts tracking progress immediately.
+func NewProgressTracker(context Context, config ProgressTrackerConfig) ProgressTracker {
+	var tracker = &progressTracker{
+		context:  context,
+		now:      time.Now,
+		progress: make(map[string]*progressState),
+		stop:     make(chan struct{}),
+	}
+
+	tracker.unregister = events.RegisterFrom(events.ListenerFunc(tracker.handle), componentFile, componentSource)
+
+	if config.LogInterval > 0 {
+		go tracker.log(config.LogInterval)
+	}
+
+	return tracker
+}
+
+//
+// Private types
+//
+
+// ProgressTracker implementation
+type progressTracker struct {
+	context    Context
+	mutex      sync.Mutex
+	now        func() time.Time
+	once       sync.Once
+	progress   map[string]*progressState
+	stop       chan struct{}
+	unregister func()
+}
+
+func (tracker *progressTracker) All() []Progress {
+	var result = make([]Progress, 0)
+
+	tracker.mutex.Lock()
+
+	for _, state := range tracker.progress {
+		result = append(result, state.snapshot(tracker.now()))
+	}
+
+	tracker.mutex.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SourceID < result[j].SourceID
+	})
+
+	return result
+}
+
+func (tracker *progressTracker) Progress(sourceID string) (Progress, bool) {
+	var state *progressState
+	var ok bool
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if state, ok = tracker.progress[sourceID]; !ok {
+		return Progress{}, false
+	}
+
+	return state.snapshot(tracker.now()), true
+}
+
+func (tracker *progressTracker) Stop() {
+	tracker.once.Do(func() {
+		tracker.unregister()
+
+		close(tracker.stop)
+	})
+}
+
+func (tracker *progressTracker) handle(evt events.Event) {
+	var state *progressState
+
+	// File events use the ID of the Source that produced the file, so they can be grouped with Source events.
+
+	if evt.Component() != componentFile && evt.Component() != componentSource {
+		return
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if state = tracker.progress[evt.ID()]; state == nil {
+		state = &progressState{
+			progress: Progress{
+				SourceID: evt.ID(),
+			},
+			start: evt.Time(),
+		}
+
+		tracker.progress[evt.ID()] = state
+	}
+
+	switch typed := evt.(type) {
+	case events.FileClosed:
+		state.progress.FilesClosed++
+
+	case events.FileOpened:
+		state.progress.BytesExpected += typed.Size
+		state.progress.FilesOpened++
+
+	case events.FileRead:
+		state.progress.BytesRead += int64(typed.Bytes)
+
+	case events.ResultProduced:
+		if typed.Error == "" {
+			state.progress.FilesSeen++
+		}
+	}
+}
+
+func (tracker *progressTracker) log(interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, progress := range tracker.All() {
+				tracker.context.Log().Info().
+					Str("id", progress.SourceID).
+					Int("filesSeen", progress.FilesSeen).
+					Int("filesOpened", progress.FilesOpened).
+					Int("filesClosed", progress.FilesClosed).
+					Int64("bytesRead", progress.BytesRead).
+					Int64("bytesExpected", progress.BytesExpected).
+					Float64("bytesPerSecond", progress.Throughput).
+					Dur("eta", progress.ETA).
+					Msg("progress")
+			}
+
+		case <-tracker.stop:
+			return
+		}
+	}
+}
+
+// Used to keep track of the progress for a single Source.
+type progressState struct {
+	progress Progress
+	start    time.Time
+}
+
+func (state *progressState) snapshot(now time.Time) Progress {
+	var result = state.progress
+
+	result.Elapsed = now.Sub(state.start)
+
+	if result.Elapsed > 0 {
+		result.Throughput = float64(result.BytesRead) / result.Elapsed.Seconds()
+	}
+
+	if result.Throughput > 0 && result.BytesExpected > result.BytesRead {
+		result.ETA = time.Duration(float64(result.BytesExpected-result.BytesRead) / result.Throughput *
+			float64(time.Second))
+	}
+
+	return result
+}
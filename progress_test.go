@@ -0,0 +1,182 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// ProgressTracker tests
+
+var _ = g.Describe("ProgressTracker", func() {
+	g.Describe("given a new instance", func() {
+		var buffer *syncBuffer
+		var id string
+		var interval time.Duration
+		var tracker ProgressTracker
+
+		g.BeforeEach(func() {
+			buffer = &syncBuffer{}
+			id = fmt.Sprintf("%d", rand.Int())
+		})
+
+		g.JustBeforeEach(func() {
+			tracker = NewProgressTracker(NewContext(ContextConfig{
+				Writer: buffer,
+			}), ProgressTrackerConfig{
+				LogInterval: interval,
+			})
+		})
+
+		g.AfterEach(func() {
+			tracker.Stop()
+		})
+
+		g.Context("which has not received any events for a Source", func() {
+			g.Describe("calling Progress", func() {
+				g.It("should return false", func() {
+					var ok bool
+
+					_, ok = tracker.Progress(id)
+
+					Expect(ok).To(BeFalse())
+				})
+			})
+		})
+
+		g.Context("which has received events for a Source", func() {
+			var start = time.Now()
+
+			g.JustBeforeEach(func() {
+				var f = &file{
+					fileInfo: &nilFileInfo{
+						name: "name",
+						size: 30,
+					},
+					path: newFilePath(nil, "name", "/"),
+				}
+				var impl = tracker.(*progressTracker)
+
+				event.Send(sourceEventStarted(id))
+				event.Send(sourceEventResultProduced(id, &result{file: f}))
+				event.Send(sourceEventResultProduced(id, &result{err: fmt.Errorf("error")}))
+				event.Send(fileEventOpened(f, id))
+				event.Send(fileEventRead(f, id, 10))
+				event.Send(fileEventRead(f, id, 10))
+
+				impl.mutex.Lock()
+
+				impl.now = func() time.Time {
+					return start.Add(10 * time.Second)
+				}
+				impl.progress[id].start = start
+
+				impl.mutex.Unlock()
+			})
+
+			g.Describe("calling Progress", func() {
+				g.It("should return the expected Progress", func() {
+					var ok bool
+					var progress Progress
+
+					progress, ok = tracker.Progress(id)
+
+					Expect(ok).To(BeTrue())
+					Expect(progress).To(Equal(Progress{
+						BytesExpected: 30,
+						BytesRead:     20,
+						ETA:           5 * time.Second,
+						Elapsed:       10 * time.Second,
+						FilesOpened:   1,
+						FilesSeen:     1,
+						SourceID:      id,
+						Throughput:    2,
+					}))
+				})
+			})
+
+			g.Describe("calling All", func() {
+				g.It("should include the Progress for the Source", func() {
+					var found bool
+
+					for _, progress := range tracker.All() {
+						if progress.SourceID == id {
+							found = true
+
+							Expect(progress.BytesRead).To(BeEquivalentTo(20))
+						}
+					}
+
+					Expect(found).To(BeTrue())
+				})
+			})
+
+			g.Describe("calling Stop", func() {
+				g.It("should stop tracking events", func() {
+					var progress Progress
+
+					tracker.Stop()
+
+					event.Send(sourceEventResultProduced(id, &result{file: &file{path: newFilePath(nil, "name",
+						"/")}}))
+
+					progress, _ = tracker.Progress(id)
+
+					Expect(progress.FilesSeen).To(Equal(1))
+				})
+			})
+		})
+
+		g.Context("with a log interval", func() {
+			g.BeforeEach(func() {
+				interval = 10 * time.Millisecond
+			})
+
+			g.AfterEach(func() {
+				interval = 0
+			})
+
+			g.It("should periodically log progress", func() {
+				event.Send(sourceEventStarted(id))
+
+				Eventually(buffer.String).Should(ContainSubstring(id))
+				Expect(buffer.String()).To(ContainSubstring("progress"))
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// Concurrency-safe bytes.Buffer used to capture log output written from other goroutines.
+type syncBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+
+	return buffer.buffer.String()
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+
+	return buffer.buffer.Write(p)
+}
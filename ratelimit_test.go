@@ -0,0 +1,234 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Testcases
+//
+
+// Rate limiting tests
+
+var _ = g.Describe("newRateLimiter", func() {
+	g.Describe("calling newRateLimiter", func() {
+		g.Context("with a rate of zero", func() {
+			g.It("should return nil", func() {
+				Expect(newRateLimiter(0)).To(BeNil())
+			})
+		})
+
+		g.Context("with a rate of less than one event per second", func() {
+			g.It("should use a burst size of one", func() {
+				Expect(newRateLimiter(0.5).Burst()).To(Equal(1))
+			})
+		})
+
+		g.Context("with a rate of more than one event per second", func() {
+			g.It("should use a burst size of one second's worth of events", func() {
+				Expect(newRateLimiter(100).Burst()).To(Equal(100))
+			})
+		})
+	})
+})
+
+var _ = g.Describe("waitForTokens", func() {
+	g.Describe("calling waitForTokens", func() {
+		g.Context("with a nil rate limiter", func() {
+			g.It("should not wait", func() {
+				Expect(waitForTokens(gocontext.Background(), nil, 1000)).To(BeNil())
+			})
+		})
+
+		g.Context("with more events than the burst size", func() {
+			g.It("should wait for every event", func() {
+				var limiter = newRateLimiter(100)
+				var start = time.Now()
+
+				Expect(waitForTokens(gocontext.Background(), limiter, 150)).To(BeNil())
+				Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+			})
+		})
+
+		g.Context("with a cancelled context.Context", func() {
+			g.It("should return an error", func() {
+				var cancel gocontext.CancelFunc
+				var waitContext gocontext.Context
+
+				waitContext, cancel = gocontext.WithCancel(gocontext.Background())
+
+				cancel()
+
+				Expect(waitForTokens(waitContext, newRateLimiter(0.01), 2)).NotTo(BeNil())
+			})
+		})
+	})
+})
+
+var _ = g.Describe("Source", func() {
+	g.Describe("given a new instance that limits listings", func() {
+		var sink *testEventSink
+		var src Source
+
+		g.BeforeEach(func() {
+			var err error
+
+			sink = newTestEventSink()
+
+			event.RegisterSink(sink)
+
+			// Only the root can be listed without waiting.
+
+			src, err = NewSource(SourceConfig{
+				ID:                sink.id,
+				ListingsPerSecond: 0.01,
+				Recurse:           true,
+			}, &memFilesystem{
+				root: &memFilesystemNode{
+					children: map[string]*memFilesystemNode{
+						"dir": {
+							children: map[string]*memFilesystemNode{
+								"file": {},
+							},
+						},
+					},
+				},
+			})
+
+			Expect(err).To(BeNil())
+		})
+
+		g.Describe("calling Files and cancelling while waiting to list a directory", func() {
+			g.It("should stop waiting and send the appropriate events", func() {
+				var cancel CancelFunc
+				var in <-chan Result
+				var invoked = make(chan struct{})
+
+				in, cancel = src.Files(NewContext(ContextConfig{}))
+
+				cancel(func() {
+					close(invoked)
+				})
+
+				Eventually(in, time.Second).Should(BeClosed())
+				Eventually(invoked).Should(BeClosed())
+				Expect(sink).To(haveTheseEvents(eventSourceCreated, eventSourceStarted, eventSourceCancelled,
+					eventSourceFinished))
+			})
+		})
+	})
+
+	g.Describe("given a new instance that limits reads", func() {
+		var src Source
+
+		g.BeforeEach(func() {
+			var err error
+
+			src, err = NewSource(SourceConfig{
+				BytesPerSecond: 20,
+				ID:             "source",
+			}, &memFilesystem{
+				root: &memFilesystemNode{
+					children: map[string]*memFilesystemNode{
+						"file": {
+							contents: strings.Repeat("a", 30),
+						},
+					},
+				},
+			})
+
+			Expect(err).To(BeNil())
+		})
+
+		g.Describe("calling Reader on a File it produced", func() {
+			g.It("should limit the rate at which the contents can be read", func() {
+				var contents []byte
+				var err error
+				var reader io.ReadCloser
+				var results = collectSourceResults(src)
+				var start time.Time
+
+				Expect(results).To(HaveLen(1))
+
+				reader, err = results[0].File().Reader()
+
+				Expect(err).To(BeNil())
+
+				start = time.Now()
+				contents, err = ioutil.ReadAll(reader)
+
+				Expect(err).To(BeNil())
+				Expect(contents).To(HaveLen(30))
+				Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+				Expect(reader.Close()).To(BeNil())
+			})
+
+			g.It("should not read more than one second's worth of contents at once", func() {
+				var amount int
+				var err error
+				var reader io.ReadCloser
+				var results = collectSourceResults(src)
+
+				Expect(results).To(HaveLen(1))
+
+				reader, err = results[0].File().Reader()
+
+				Expect(err).To(BeNil())
+
+				amount, err = reader.Read(make([]byte, 1024))
+
+				Expect(err).To(BeNil())
+				Expect(amount).To(Equal(20))
+				Expect(reader.Close()).To(BeNil())
+			})
+		})
+
+		g.Describe("calling Reader on a File it produced and cancelling the Source while reading", func() {
+			g.It("should stop waiting", func() {
+				var cancel CancelFunc
+				var done = make(chan error, 1)
+				var err error
+				var in <-chan Result
+				var reader io.ReadCloser
+				var res Result
+
+				in, cancel = src.Files(NewContext(ContextConfig{}))
+
+				Eventually(in).Should(Receive(&res))
+
+				reader, err = res.File().Reader()
+
+				Expect(err).To(BeNil())
+
+				go func() {
+					var err error
+
+					_, err = ioutil.ReadAll(reader)
+
+					done <- err
+				}()
+
+				cancel(nil)
+
+				for range in {
+				}
+
+				// Reading the whole File takes at least 500 milliseconds unless waiting is interrupted.
+
+				Eventually(done, 250*time.Millisecond).Should(Receive(&err))
+				Expect(errors.Is(err, gocontext.Canceled)).To(BeTrue())
+				Expect(reader.Close()).To(BeNil())
+			})
+		})
+	})
+})
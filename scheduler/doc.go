@@ -0,0 +1 @@
+package scheduler // import "golang.handcraftedbits.com/pipewerx/scheduler"
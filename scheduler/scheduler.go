@@ -0,0 +1,393 @@
+package scheduler // import "golang.handcraftedbits.com/pipewerx/scheduler"
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Public types
+//
+
+type JobConfig struct {
+	// Cron is a standard five-field cron expression (e.g., "0 2 * * *"), which also accepts descriptors such as
+	// "@daily" and "@every 1h30m".  Exactly one of Cron and Interval must be set.
+	Cron string
+
+	// ID identifies the Job.  A Job is never run again while a previous run with the same ID is still in progress.
+	ID string
+
+	// Interval is the time between the start of one run and the start of the next.  The first run starts one Interval
+	// after the Job is scheduled.
+	Interval time.Duration
+
+	Run JobFunc
+}
+
+// JobFunc runs a pipeline.  Each run receives its own copy of the Scheduler's pipewerx.Context.  The stop channel is
+// closed when the Scheduler is shut down, at which point the JobFunc should cancel its Sources and return as soon as
+// possible.
+type JobFunc func(context pipewerx.Context, stop <-chan struct{}) error
+
+// JobStatus describes the state of a Job.
+type JobStatus struct {
+	ID string
+
+	// LastError is the error returned by the most recent run that has finished, if any.
+	LastError error
+
+	// LastFinish is the time at which the most recent run finished.  It is zero if no run has finished.
+	LastFinish time.Time
+
+	// LastStart is the time at which the most recent run started.  It is zero if the Job has never run.
+	LastStart time.Time
+
+	// NextRun is the time at which the Job is next scheduled to run.  It is zero if the Scheduler isn't running.
+	NextRun time.Time
+
+	Running bool
+
+	// Runs is the number of runs that have started.
+	Runs int
+
+	// Skipped is the number of runs that were skipped because the previous run was still in progress.
+	Skipped int
+}
+
+// Scheduler runs pipelines repeatedly, according to a schedule.
+type Scheduler interface {
+	// Add schedules a Job.  Jobs can be added before or after the Scheduler is started.
+	Add(config JobConfig) error
+
+	// Shutdown stops scheduling runs and signals every run in progress to stop, then waits for them to finish.  If the
+	// context.Context is done before they finish, its error is returned.  The Scheduler can't be restarted.
+	Shutdown(ctx gocontext.Context) error
+
+	// Start begins running Jobs according to their schedules.
+	Start()
+
+	// Status retrieves the JobStatus for a Job, returning false if no Job with the given ID has been added.
+	Status(id string) (JobStatus, bool)
+
+	// Statuses retrieves the JobStatus for every Job, sorted by ID.
+	Statuses() []JobStatus
+}
+
+type SchedulerConfig struct {
+	// Context is copied for each run.  A new pipewerx.Context is created if none is provided.
+	Context pipewerx.Context
+}
+
+//
+// Public functions
+//
+
+func NewScheduler(config SchedulerConfig) Scheduler {
+	if config.Context == nil {
+		config.Context = pipewerx.NewContext(pipewerx.ContextConfig{})
+	}
+
+	return &scheduler{
+		config: config,
+		jobs:   make(map[string]*scheduledJob),
+		stop:   make(chan struct{}),
+	}
+}
+
+//
+// Private types
+//
+
+// Interval schedule implementation
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (sched *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(sched.interval)
+}
+
+// Determines when a Job runs.  This is satisfied by cron.Schedule.
+type schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// A scheduled JobConfig and its JobStatus.
+type scheduledJob struct {
+	config   JobConfig
+	schedule schedule
+	status   JobStatus
+}
+
+// Scheduler implementation
+type scheduler struct {
+	config  SchedulerConfig
+	jobs    map[string]*scheduledJob
+	mutex   sync.Mutex
+	started bool
+	stop    chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func (sched *scheduler) Add(config JobConfig) error {
+	var err error
+	var newJob = &scheduledJob{
+		config: config,
+		status: JobStatus{
+			ID: config.ID,
+		},
+	}
+
+	if newJob.schedule, err = newSchedule(config); err != nil {
+		return err
+	}
+
+	sched.mutex.Lock()
+	defer sched.mutex.Unlock()
+
+	if sched.stopped {
+		return errSchedulerStopped
+	}
+
+	if _, ok := sched.jobs[config.ID]; ok {
+		return fmt.Errorf("%w: %s", errSchedulerDuplicateID, config.ID)
+	}
+
+	sched.jobs[config.ID] = newJob
+
+	if sched.started {
+		sched.startJob(newJob)
+	}
+
+	return nil
+}
+
+func (sched *scheduler) Shutdown(ctx gocontext.Context) error {
+	var done = make(chan struct{})
+
+	sched.mutex.Lock()
+
+	if !sched.stopped {
+		sched.stopped = true
+
+		close(sched.stop)
+	}
+
+	sched.mutex.Unlock()
+
+	go func() {
+		sched.wg.Wait()
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (sched *scheduler) Start() {
+	sched.mutex.Lock()
+	defer sched.mutex.Unlock()
+
+	if sched.started || sched.stopped {
+		return
+	}
+
+	sched.started = true
+
+	for _, job := range sched.jobs {
+		sched.startJob(job)
+	}
+}
+
+func (sched *scheduler) Status(id string) (JobStatus, bool) {
+	var job *scheduledJob
+	var ok bool
+
+	sched.mutex.Lock()
+	defer sched.mutex.Unlock()
+
+	if job, ok = sched.jobs[id]; !ok {
+		return JobStatus{}, false
+	}
+
+	return job.status, true
+}
+
+func (sched *scheduler) Statuses() []JobStatus {
+	var statuses []JobStatus
+
+	sched.mutex.Lock()
+
+	statuses = make([]JobStatus, 0, len(sched.jobs))
+
+	for _, job := range sched.jobs {
+		statuses = append(statuses, job.status)
+	}
+
+	sched.mutex.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID < statuses[j].ID
+	})
+
+	return statuses
+}
+
+// Waits for a Job's scheduled times and starts a run at each one, unless the previous run is still in progress.
+func (sched *scheduler) loop(job *scheduledJob) {
+	defer sched.wg.Done()
+
+	for {
+		var next = job.schedule.Next(time.Now())
+		var timer = time.NewTimer(time.Until(next))
+
+		sched.mutex.Lock()
+
+		job.status.NextRun = next
+
+		sched.mutex.Unlock()
+
+		select {
+		case <-sched.stop:
+			timer.Stop()
+
+			sched.mutex.Lock()
+
+			job.status.NextRun = time.Time{}
+
+			sched.mutex.Unlock()
+
+			return
+
+		case <-timer.C:
+		}
+
+		sched.mutex.Lock()
+
+		// select chooses randomly if Shutdown() was called just as the timer fired, so check again before running.
+
+		if sched.stopped {
+			job.status.NextRun = time.Time{}
+
+			sched.mutex.Unlock()
+
+			return
+		}
+
+		if job.status.Running {
+			job.status.Skipped++
+
+			sched.mutex.Unlock()
+
+			sched.config.Context.Log().Warn().
+				Str("id", job.config.ID).
+				Msg("skipping run since the previous run is still in progress")
+
+			continue
+		}
+
+		job.status.LastStart = time.Now()
+		job.status.Running = true
+		job.status.Runs++
+
+		// Shutdown() waits for runs as well as loops.
+
+		sched.wg.Add(1)
+
+		sched.mutex.Unlock()
+
+		go sched.run(job)
+	}
+}
+
+func (sched *scheduler) run(job *scheduledJob) {
+	var err error
+
+	defer sched.wg.Done()
+
+	err = runJob(job.config.Run, sched.config.Context.Copy(), sched.stop)
+
+	if err != nil {
+		sched.config.Context.Log().Error().
+			Str("id", job.config.ID).
+			Err(err).
+			Msg("run failed")
+	}
+
+	sched.mutex.Lock()
+
+	job.status.LastError = err
+	job.status.LastFinish = time.Now()
+	job.status.Running = false
+
+	sched.mutex.Unlock()
+}
+
+// Must be called with the mutex held.
+func (sched *scheduler) startJob(job *scheduledJob) {
+	sched.wg.Add(1)
+
+	go sched.loop(job)
+}
+
+//
+// Private variables
+//
+
+var (
+	errSchedulerDuplicateID = errors.New("a Job with the same ID has already been added")
+	errSchedulerEmptyID     = errors.New("cannot add a Job without an ID")
+	errSchedulerNilRun      = errors.New("cannot add a Job without a JobFunc")
+	errSchedulerSchedule    = errors.New("exactly one of Cron and Interval must be set")
+	errSchedulerStopped     = errors.New("cannot add a Job after the Scheduler has been shut down")
+)
+
+//
+// Private functions
+//
+
+func newSchedule(config JobConfig) (schedule, error) {
+	switch {
+	case config.ID == "":
+		return nil, errSchedulerEmptyID
+
+	case config.Run == nil:
+		return nil, errSchedulerNilRun
+
+	case (config.Cron == "") == (config.Interval <= 0):
+		return nil, errSchedulerSchedule
+
+	case config.Interval > 0:
+		return &intervalSchedule{
+			interval: config.Interval,
+		}, nil
+	}
+
+	return cron.ParseStandard(config.Cron)
+}
+
+// Runs a JobFunc, converting a panic into an error so that it doesn't take down the Scheduler.
+func runJob(run JobFunc, context pipewerx.Context, stop <-chan struct{}) (e error) {
+	defer func() {
+		if value := recover(); value != nil {
+			e = fmt.Errorf("run panicked: %v", value)
+		}
+	}()
+
+	return run(context, stop)
+}
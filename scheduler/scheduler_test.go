@@ -0,0 +1,278 @@
+package scheduler // import "golang.handcraftedbits.com/pipewerx/scheduler"
+
+import (
+	gocontext "context"
+	"errors"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Testcases
+//
+
+// Scheduler tests
+
+var _ = Describe("Scheduler", func() {
+	Describe("given a new instance", func() {
+		var sched Scheduler
+
+		BeforeEach(func() {
+			sched = NewScheduler(SchedulerConfig{
+				Context: pipewerx.NewContext(pipewerx.ContextConfig{
+					Writer: ioutil.Discard,
+				}),
+			})
+		})
+
+		AfterEach(func() {
+			Expect(sched.Shutdown(gocontext.Background())).To(BeNil())
+		})
+
+		Describe("calling Add", func() {
+			var noop = func(context pipewerx.Context, stop <-chan struct{}) error {
+				return nil
+			}
+
+			It("should return an error if the JobConfig is invalid", func() {
+				Expect(errors.Is(sched.Add(JobConfig{Interval: time.Second, Run: noop}), errSchedulerEmptyID)).To(
+					BeTrue())
+				Expect(errors.Is(sched.Add(JobConfig{ID: "job", Interval: time.Second}), errSchedulerNilRun)).To(
+					BeTrue())
+				Expect(errors.Is(sched.Add(JobConfig{ID: "job", Run: noop}), errSchedulerSchedule)).To(BeTrue())
+				Expect(errors.Is(sched.Add(JobConfig{Cron: "@daily", ID: "job", Interval: time.Second, Run: noop}),
+					errSchedulerSchedule)).To(BeTrue())
+				Expect(sched.Add(JobConfig{Cron: "invalid", ID: "job", Run: noop})).NotTo(BeNil())
+			})
+
+			It("should return an error if a Job with the same ID has already been added", func() {
+				Expect(sched.Add(JobConfig{Cron: "@daily", ID: "job", Run: noop})).To(BeNil())
+				Expect(errors.Is(sched.Add(JobConfig{Interval: time.Second, ID: "job", Run: noop}),
+					errSchedulerDuplicateID)).To(BeTrue())
+			})
+
+			It("should return an error after the Scheduler has been shut down", func() {
+				Expect(sched.Shutdown(gocontext.Background())).To(BeNil())
+				Expect(errors.Is(sched.Add(JobConfig{Cron: "@daily", ID: "job", Run: noop}),
+					errSchedulerStopped)).To(BeTrue())
+			})
+		})
+
+		Describe("calling Start", func() {
+			It("should schedule Jobs using cron expressions", func() {
+				var status JobStatus
+				var ok bool
+
+				Expect(sched.Add(JobConfig{
+					Cron: "0 0 1 1 *",
+					ID:   "job",
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						return nil
+					},
+				})).To(BeNil())
+
+				status, ok = sched.Status("job")
+
+				Expect(ok).To(BeTrue())
+				Expect(status.NextRun.IsZero()).To(BeTrue())
+
+				sched.Start()
+
+				Eventually(func() time.Time {
+					status, _ = sched.Status("job")
+
+					return status.NextRun
+				}).ShouldNot(BeZero())
+
+				Expect(status.NextRun.Month()).To(Equal(time.January))
+				Expect(status.NextRun.Day()).To(Equal(1))
+				Expect(status.Runs).To(BeZero())
+			})
+
+			It("should run Jobs repeatedly and record their status", func() {
+				var runs int32
+
+				Expect(sched.Add(JobConfig{
+					ID:       "job",
+					Interval: 10 * time.Millisecond,
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						if atomic.AddInt32(&runs, 1) == 1 {
+							return errors.New("run")
+						}
+
+						panic("panic")
+					},
+				})).To(BeNil())
+
+				sched.Start()
+
+				Eventually(func() int32 {
+					return atomic.LoadInt32(&runs)
+				}).Should(BeNumerically(">=", 2))
+
+				Expect(sched.Shutdown(gocontext.Background())).To(BeNil())
+
+				Expect(sched.Statuses()).To(HaveLen(1))
+				Expect(sched.Statuses()[0].ID).To(Equal("job"))
+				Expect(sched.Statuses()[0].LastError).To(MatchError("run panicked: panic"))
+				Expect(sched.Statuses()[0].LastFinish.IsZero()).To(BeFalse())
+				Expect(sched.Statuses()[0].LastStart.IsZero()).To(BeFalse())
+				Expect(sched.Statuses()[0].NextRun.IsZero()).To(BeTrue())
+				Expect(sched.Statuses()[0].Running).To(BeFalse())
+				Expect(sched.Statuses()[0].Runs).To(BeEquivalentTo(atomic.LoadInt32(&runs)))
+			})
+
+			It("should not run a Job while its previous run is still in progress", func() {
+				var release = make(chan struct{})
+				var runs int32
+				var status JobStatus
+
+				Expect(sched.Add(JobConfig{
+					ID:       "job",
+					Interval: 10 * time.Millisecond,
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						atomic.AddInt32(&runs, 1)
+
+						<-release
+
+						return nil
+					},
+				})).To(BeNil())
+
+				sched.Start()
+
+				Eventually(func() int {
+					status, _ = sched.Status("job")
+
+					return status.Skipped
+				}).Should(BeNumerically(">=", 2))
+
+				Expect(status.Running).To(BeTrue())
+				Expect(atomic.LoadInt32(&runs)).To(BeEquivalentTo(1))
+
+				close(release)
+			})
+
+			It("should start Jobs that are added after the Scheduler has started", func() {
+				var ran = make(chan struct{}, 1)
+
+				sched.Start()
+
+				Expect(sched.Add(JobConfig{
+					ID:       "job",
+					Interval: 10 * time.Millisecond,
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						select {
+						case ran <- struct{}{}:
+
+						default:
+						}
+
+						return nil
+					},
+				})).To(BeNil())
+
+				Eventually(ran).Should(Receive())
+			})
+		})
+
+		Describe("calling Shutdown", func() {
+			It("should signal runs in progress to stop and wait for them", func() {
+				var started = make(chan struct{})
+				var stopped int32
+
+				Expect(sched.Add(JobConfig{
+					ID:       "job",
+					Interval: 10 * time.Millisecond,
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						close(started)
+
+						<-stop
+
+						time.Sleep(10 * time.Millisecond)
+
+						atomic.StoreInt32(&stopped, 1)
+
+						return nil
+					},
+				})).To(BeNil())
+
+				sched.Start()
+
+				Eventually(started).Should(BeClosed())
+
+				Expect(sched.Shutdown(gocontext.Background())).To(BeNil())
+				Expect(atomic.LoadInt32(&stopped)).To(BeEquivalentTo(1))
+			})
+
+			It("should return an error if runs don't finish in time", func() {
+				var ctx, cancel = gocontext.WithTimeout(gocontext.Background(), 10*time.Millisecond)
+				var release = make(chan struct{})
+				var started = make(chan struct{})
+
+				defer cancel()
+
+				Expect(sched.Add(JobConfig{
+					ID:       "job",
+					Interval: 10 * time.Millisecond,
+					Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+						close(started)
+
+						<-release
+
+						return nil
+					},
+				})).To(BeNil())
+
+				sched.Start()
+
+				Eventually(started).Should(BeClosed())
+
+				Expect(sched.Shutdown(ctx)).To(Equal(gocontext.DeadlineExceeded))
+
+				close(release)
+			})
+
+			It("should not start a run once it has been called, even if a run is due", func() {
+				var impl = sched.(*scheduler)
+				var job = &scheduledJob{
+					config: JobConfig{
+						ID: "job",
+						Run: func(context pipewerx.Context, stop <-chan struct{}) error {
+							return nil
+						},
+					},
+					schedule: &intervalSchedule{interval: -time.Second},
+				}
+
+				Expect(sched.Shutdown(gocontext.Background())).To(BeNil())
+
+				// Both the stop channel and the timer are ready, so make sure the timer never wins.
+
+				for i := 0; i < 100; i++ {
+					impl.wg.Add(1)
+					impl.loop(job)
+				}
+
+				Expect(job.status.Runs).To(BeZero())
+				Expect(job.status.NextRun.IsZero()).To(BeTrue())
+			})
+		})
+
+		Describe("calling Status", func() {
+			It("should return false for an unknown Job", func() {
+				var ok bool
+
+				_, ok = sched.Status("unknown")
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,18 @@
+package scheduler // import "golang.handcraftedbits.com/pipewerx/scheduler"
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+func TestSuiteScheduler(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "scheduler")
+}
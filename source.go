@@ -1,7 +1,13 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	gocontext "context"
 	"sync"
+	"text/template"
+	"time"
+
+	"go.opentelemetry.io/otel/api/trace"
+	"golang.org/x/time/rate"
 
 	"golang.handcraftedbits.com/pipewerx/internal/event"
 )
@@ -19,9 +25,54 @@ type Source interface {
 }
 
 type SourceConfig struct {
-	ID      string
+	// BytesPerSecond limits the rate at which the contents of the Files produced by this Source can be read.  Reading
+	// is not limited if BytesPerSecond is zero.  Limited reads return an error once the Source is cancelled, rather
+	// than waiting.
+	BytesPerSecond int64
+
+	// CheckpointInterval is the number of Files produced between Checkpoints.  DefaultCheckpointInterval is used if
+	// CheckpointInterval is not set.
+	CheckpointInterval int
+
+	// Checkpoints is used to save a Checkpoint periodically and whenever the Source stops before it has produced every
+	// File, so that the next call to Files() resumes where the Source left off.  Since Checkpoints are only saved
+	// periodically, Files produced after the most recent Checkpoint are produced again if the process exits
+	// unexpectedly.  The Checkpoint is cleared once the Source has produced every File.
+	Checkpoints CheckpointStore
+
+	ErrorPolicy ErrorPolicy
+
+	// Hashes contains the HashAlgorithms used to compute digests while Files are read.  Digests are attached to a File
+	// (see HashAlgorithm.AttributeKey()) once it has been read in full.
+	Hashes []HashAlgorithm
+
+	ID string
+
+	// ListingsPerSecond limits the rate at which directories can be listed.  Listing is not limited if
+	// ListingsPerSecond is zero.
+	ListingsPerSecond float64
+
 	Recurse bool
-	Root    string
+
+	// Root is the path at which the Source starts looking for Files.  Root can be a template (see ExpandTemplate()),
+	// which is expanded using the Context passed to Files() so that the same Source can be used for different dates,
+	// tenants, etc.  The Filesystem must be a RootedFilesystem in that case.
+	Root string
+
+	// Watch determines whether the Source keeps running once it has produced every File under Root, producing Files
+	// that are added or changed until it is cancelled.  Changes are detected as they happen if the Filesystem is a
+	// WatchableFilesystem, or by listing Root every WatchPollInterval otherwise.  Every File, including those found
+	// when the Source starts, is only produced once it has settled (see WatchSettleTime), and Checkpoints aren't used.
+	Watch bool
+
+	// WatchPollInterval is the time between listings when watching.  DefaultWatchPollInterval is used if
+	// WatchPollInterval is not set.
+	WatchPollInterval time.Duration
+
+	// WatchSettleTime is how long an added or changed File's size and modification time must stay the same before it
+	// is produced, so that Files that are still being written aren't produced.  DefaultWatchSettleTime is used if
+	// WatchSettleTime is not set.
+	WatchSettleTime time.Duration
 }
 
 //
@@ -30,6 +81,7 @@ type SourceConfig struct {
 
 func NewSource(config SourceConfig, fs Filesystem) (Source, error) {
 	var err error
+	var rootTemplate *template.Template
 
 	if fs == nil {
 		return nil, errSourceNilFilesystem
@@ -41,13 +93,32 @@ func NewSource(config SourceConfig, fs Filesystem) (Source, error) {
 		return nil, err
 	}
 
+	for _, algorithm := range config.Hashes {
+		if _, err = newHash(algorithm); err != nil {
+			return nil, err
+		}
+	}
+
+	if isTemplate(config.Root) {
+		if _, ok := fs.(RootedFilesystem); !ok {
+			return nil, errSourceNotRooted
+		}
+
+		if rootTemplate, err = newTemplate(config.Root); err != nil {
+			return nil, err
+		}
+	}
+
 	if event.IsAllowedFrom(componentSource) {
 		event.Send(sourceEventCreated(config.ID))
 	}
 
 	return &source{
-		config: config,
-		fs:     fs,
+		config:       config,
+		fs:           fs,
+		listLimiter:  newRateLimiter(config.ListingsPerSecond),
+		readLimiter:  newRateLimiter(float64(config.BytesPerSecond)),
+		rootTemplate: rootTemplate,
 	}, nil
 }
 
@@ -67,7 +138,7 @@ func (merged *mergedSource) Files(context Context) (<-chan Result, CancelFunc) {
 	var out = make(chan Result)
 	var wg sync.WaitGroup
 
-	cancelHelper = newCancellationHelper(context.Log(), out, cancel, &wg)
+	cancelHelper = newCancellationHelper(context.Log(), componentSource, merged.id, out, cancel, &wg)
 
 	wg.Add(len(merged.sources))
 
@@ -122,22 +193,51 @@ func (merged *mergedSource) destroy() error {
 
 // Default Source implementation
 type source struct {
-	config SourceConfig
-	fs     Filesystem
+	config       SourceConfig
+	fs           Filesystem
+	listLimiter  *rate.Limiter
+	readLimiter  *rate.Limiter
+	rootTemplate *template.Template
 }
 
 func (src *source) Files(context Context) (<-chan Result, CancelFunc) {
 	var cancel = make(chan struct{})
 	var cancelHelper *cancellationHelper
+	var invoke CancelFunc
 	var out = make(chan Result)
+	var readContext, stopReading = gocontext.WithCancel(gocontext.Background())
+	var span trace.Span
 
-	cancelHelper = newCancellationHelper(context.Log(), out, cancel, nil)
+	context, span = startSpan(context, SpanSourceFiles, traceAttributeSourceID(src.config.ID))
+
+	cancelHelper = newCancellationHelper(context.Log(), componentSource, src.config.ID, out, cancel, nil)
 
 	go func() {
 		var err error
+		var errorHelper = newErrorPolicyHelper(context.Log(), src.config.ID, src.config.ErrorPolicy)
 		var f *file
-		var res *result
+		var fs Filesystem
+		var produced int
+		var res Result
+		var root string
+		var send func(res Result) bool
 		var stepper *pathStepper
+		var stop bool
+		var waitContext, stopWaiting = gocontext.WithCancel(gocontext.Background())
+		var watchRoot string
+
+		// Rate limiting can block for a while, so make sure that cancellation interrupts it.
+
+		defer stopWaiting()
+
+		go func() {
+			select {
+			case <-cancel:
+				stopWaiting()
+
+			case <-waitContext.Done():
+			}
+		}()
 
 		if event.IsAllowedFrom(componentSource) {
 			event.Send(sourceEventStarted(src.config.ID))
@@ -148,62 +248,285 @@ func (src *source) Files(context Context) (<-chan Result, CancelFunc) {
 				event.Send(sourceEventFinished(src.config.ID))
 			}
 
+			span.End()
+
 			cancelHelper.finalize()
 		}()
 
-		if stepper, err = newPathStepper(src.fs, src.config.Root, src.config.Recurse); err != nil {
-			res = &result{
-				err: err,
-			}
+		send = func(res Result) bool {
+			select {
+			case out <- res:
+				if event.IsAllowedFrom(componentSource) {
+					event.Send(sourceEventResultProduced(src.config.ID, res))
+				}
 
-			out <- res
+				if res.Error() != nil {
+					span.RecordError(traceContextOf(context), res.Error())
+				}
 
-			if event.IsAllowedFrom(componentSource) {
-				event.Send(sourceEventResultProduced(src.config.ID, res))
+				return true
+
+			case <-cancel:
+				if event.IsAllowedFrom(componentSource) {
+					event.Send(sourceEventCancelled(src.config.ID))
+				}
+
+				return false
 			}
+		}
 
-			return
+		if fs, root, err = src.resolveRoot(context); err == nil {
+			if src.config.Watch {
+				// Watching lists the root path itself and doesn't use Checkpoints, so only the absolute root path is
+				// needed.
+
+				watchRoot, err = absolutePath(fs, root)
+			} else {
+				stepper, err = src.newPathStepper(waitContext, fs, root)
+			}
 		}
 
-		for {
-			f, err = stepper.nextFile()
+		if err != nil {
+			if waitContext.Err() != nil {
+				// The Source was cancelled while waiting to list the root path.
+
+				if event.IsAllowedFrom(componentSource) {
+					event.Send(sourceEventCancelled(src.config.ID))
+				}
 
-			if f == nil && err == nil {
 				return
 			}
 
-			// Add in our ID so File.Reader() can send proper events later.
+			res, _ = errorHelper.handle(&result{
+				err: withSourceID(src.config.ID, OperationStat, err),
+			})
 
-			f.sourceID = src.ID()
-
-			res = &result{
-				err:  err,
-				file: f,
+			if res != nil && !send(res) {
+				return
 			}
+		} else if src.config.Watch {
+			// Watching only stops once the Source is cancelled or an error stops it, so the Source never finishes.
+
+			(&sourceWatch{
+				cancel:      cancel,
+				context:     context,
+				errorHelper: errorHelper,
+				fs:          fs,
+				pending:     make(map[string]pendingWatchedFile),
+				readContext: readContext,
+				root:        root,
+				send:        send,
+				src:         src,
+				waitContext: waitContext,
+				watched:     make(map[string]watchedFile),
+			}).run(watchRoot)
 
-			select {
-			case out <- res:
-				if event.IsAllowedFrom(componentSource) {
-					event.Send(sourceEventResultProduced(src.config.ID, res))
+			return
+		} else {
+			for {
+				f, err = stepper.nextFile()
+
+				if f == nil && err == nil {
+					break
 				}
 
-			case <-cancel:
-				if event.IsAllowedFrom(componentSource) {
-					event.Send(sourceEventCancelled(src.config.ID))
+				if err != nil && waitContext.Err() != nil {
+					// The Source was cancelled while waiting to list a directory.
+
+					if event.IsAllowedFrom(componentSource) {
+						event.Send(sourceEventCancelled(src.config.ID))
+					}
+
+					stepper.relist()
+					src.saveCheckpoint(context, stepper, root)
+
+					return
+				}
+
+				if err != nil {
+					res, stop = errorHelper.handle(&result{
+						err: withSourceID(src.config.ID, OperationList, err),
+					})
+				} else {
+					res = src.newFileResult(context, readContext, f)
 				}
 
+				if res != nil && !send(res) {
+					// The file, or the directory that couldn't be listed, wasn't handled, so make sure it's handled
+					// when the Source resumes.
+
+					if f != nil {
+						stepper.unread()
+					} else {
+						stepper.relist()
+					}
+
+					src.saveCheckpoint(context, stepper, root)
+
+					return
+				}
+
+				if stop {
+					// Retry the directory that couldn't be listed, rather than dropping it, when the Source resumes.
+
+					stepper.relist()
+					src.saveCheckpoint(context, stepper, root)
+
+					return
+				}
+
+				if f != nil && src.config.Checkpoints != nil {
+					produced++
+
+					if produced%src.checkpointInterval() == 0 && !src.handleCheckpointError(
+						src.config.Checkpoints.Save(src.config.ID, src.newCheckpoint(stepper, root)), errorHelper,
+						send) {
+						return
+					}
+				}
+			}
+
+			if src.config.Checkpoints != nil && !src.handleCheckpointError(src.config.Checkpoints.Clear(src.config.ID),
+				errorHelper, send) {
 				return
 			}
 		}
+
+		if res = errorHelper.finish(); res != nil {
+			send(res)
+		}
 	}()
 
-	return out, cancelHelper.invoker()
+	// Files are often read after the Source has finished, so rate limited reads are only interrupted if the Source is
+	// cancelled.
+
+	invoke = cancelHelper.invoker()
+
+	return out, func(callback func()) {
+		stopReading()
+
+		invoke(callback)
+	}
+}
+
+func (src *source) checkpointInterval() int {
+	if src.config.CheckpointInterval <= 0 {
+		return DefaultCheckpointInterval
+	}
+
+	return src.config.CheckpointInterval
+}
+
+// Applies the ErrorPolicy to an error encountered while saving or clearing a Checkpoint, returning false if the Source
+// should stop.
+func (src *source) handleCheckpointError(err error, errorHelper *errorPolicyHelper, send func(res Result) bool) bool {
+	var res Result
+	var stop bool
+
+	if err == nil {
+		return true
+	}
+
+	res, stop = errorHelper.handle(&result{
+		err: newPathError(src.config.ID, OperationCheckpoint, "", err),
+	})
+
+	return (res == nil || send(res)) && !stop
 }
 
 func (src *source) ID() string {
 	return src.config.ID
 }
 
+func (src *source) newCheckpoint(stepper *pathStepper, root string) *Checkpoint {
+	var checkpoint = stepper.checkpoint()
+
+	checkpoint.Recurse = src.config.Recurse
+	checkpoint.Root = root
+
+	return checkpoint
+}
+
+// Adds in our ID, hashes, rate limit, and tracing information so File.Reader() can send proper events, compute
+// digests, limit reads, and create tracing spans later.  Rate limited reads stop once readContext is done.
+func (src *source) newFileResult(context Context, readContext gocontext.Context, f *file) Result {
+	f.hashes = src.config.Hashes
+	f.readContext = readContext
+	f.readLimiter = src.readLimiter
+	f.sourceID = src.ID()
+	f.traceContext = traceContextOf(context)
+	f.tracer = tracerOf(context)
+
+	return &result{
+		file: f,
+	}
+}
+
+// Creates a pathStepper that resumes from the saved Checkpoint, if there is one that matches the SourceConfig and root
+// path.  The pathStepper uses the given context.Context to wait for the listing rate limit.
+func (src *source) newPathStepper(waitContext gocontext.Context, fs Filesystem, root string) (*pathStepper, error) {
+	var checkpoint *Checkpoint
+	var err error
+	var stepper *pathStepper
+
+	if src.config.Checkpoints != nil {
+		if checkpoint, err = src.config.Checkpoints.Load(src.config.ID); err != nil {
+			return nil, newPathError(src.config.ID, OperationCheckpoint, "", err)
+		}
+	}
+
+	if checkpoint != nil && checkpoint.Recurse == src.config.Recurse && checkpoint.Root == root {
+		stepper = newPathStepperFromCheckpoint(fs, checkpoint)
+	} else {
+		// Creating a pathStepper lists the root path.
+
+		if err = waitForTokens(waitContext, src.listLimiter, 1); err != nil {
+			return nil, err
+		}
+
+		if stepper, err = newPathStepper(fs, root, src.config.Recurse); err != nil {
+			return nil, err
+		}
+	}
+
+	stepper.listLimiter = src.listLimiter
+	stepper.waitContext = waitContext
+
+	return stepper, nil
+}
+
+// Determines the root path for a call to Files(), expanding it if it's a template, along with the Filesystem that uses
+// it.
+func (src *source) resolveRoot(context Context) (Filesystem, string, error) {
+	var err error
+	var root string
+
+	if src.rootTemplate == nil {
+		return src.fs, src.config.Root, nil
+	}
+
+	if root, err = executeTemplate(src.rootTemplate, context, nil); err != nil {
+		return nil, "", newPathError("", OperationStat, src.config.Root, err)
+	}
+
+	return src.fs.(RootedFilesystem).WithRoot(root), root, nil
+}
+
+// Saves a Checkpoint when the Source stops early.  Errors can't be sent downstream at that point, so they're logged
+// instead.
+func (src *source) saveCheckpoint(context Context, stepper *pathStepper, root string) {
+	if src.config.Checkpoints == nil {
+		return
+	}
+
+	if err := src.config.Checkpoints.Save(src.config.ID, src.newCheckpoint(stepper, root)); err != nil {
+		context.Log().Warn().
+			Str("id", src.config.ID).
+			Err(err).
+			Msg("unable to save checkpoint")
+	}
+}
+
 func (src *source) destroy() error {
 	if event.IsAllowedFrom(componentSource) {
 		event.Send(sourceEventDestroyed(src.config.ID))
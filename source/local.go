@@ -1,6 +1,8 @@
 package source // import "golang.handcraftedbits.com/pipewerx/source"
 
 import (
+	"time"
+
 	"golang.handcraftedbits.com/pipewerx"
 	"golang.handcraftedbits.com/pipewerx/internal/filesystem"
 )
@@ -10,9 +12,18 @@ import (
 //
 
 type LocalConfig struct {
-	ID      string
-	Recurse bool
-	Root    string
+	BytesPerSecond     int64
+	CheckpointInterval int
+	Checkpoints        pipewerx.CheckpointStore
+	ErrorPolicy        pipewerx.ErrorPolicy
+	Hashes             []pipewerx.HashAlgorithm
+	ID                 string
+	ListingsPerSecond  float64
+	Recurse            bool
+	Root               string
+	Watch              bool
+	WatchPollInterval  time.Duration
+	WatchSettleTime    time.Duration
 }
 
 //
@@ -21,8 +32,17 @@ type LocalConfig struct {
 
 func Local(config LocalConfig) (pipewerx.Source, error) {
 	return pipewerx.NewSource(pipewerx.SourceConfig{
-		ID:      config.ID,
-		Recurse: config.Recurse,
-		Root:    config.Root,
+		BytesPerSecond:     config.BytesPerSecond,
+		CheckpointInterval: config.CheckpointInterval,
+		Checkpoints:        config.Checkpoints,
+		ErrorPolicy:        config.ErrorPolicy,
+		Hashes:             config.Hashes,
+		ID:                 config.ID,
+		ListingsPerSecond:  config.ListingsPerSecond,
+		Recurse:            config.Recurse,
+		Root:               config.Root,
+		Watch:              config.Watch,
+		WatchPollInterval:  config.WatchPollInterval,
+		WatchSettleTime:    config.WatchSettleTime,
 	}, filesystem.Local(config.Root))
 }
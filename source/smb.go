@@ -1,6 +1,8 @@
 package source // import "golang.handcraftedbits.com/pipewerx/source"
 
 import (
+	"time"
+
 	"golang.handcraftedbits.com/pipewerx"
 	"golang.handcraftedbits.com/pipewerx/internal/filesystem"
 )
@@ -9,20 +11,110 @@ import (
 // Public types
 //
 
+// SMBAuth determines how an SMB Source authenticates with a server.
+type SMBAuth = filesystem.SMBAuth
+
 type SMBConfig struct {
-	Domain   string
-	Host     string
-	ID       string
-	Password string
-	Port     int
-	Recurse  bool
-	Root     string
-	Share    string
-	Username string
+	Auth                SMBAuth
+	BytesPerSecond      int64
+	CheckpointInterval  int
+	Checkpoints         pipewerx.CheckpointStore
+	Credentials         SMBCredentialProvider
+	Domain              string
+	Encryption          SMBEncryption
+	ErrorPolicy         pipewerx.ErrorPolicy
+	Hashes              []pipewerx.HashAlgorithm
+	Host                string
+	ID                  string
+	ListingsPerSecond   float64
+	MaxConnections      int
+	MaxIdleTime         time.Duration
+	MaxProtocol         SMBProtocol
+	MinProtocol         SMBProtocol
+	Password            string
+	Port                int
+	Recurse             bool
+	Root                string
+	SecurityDescriptors bool
+	Share               string
+	Signing             SMBSigning
+	Timeout             time.Duration
+	Transport           SMBTransport
+	Username            string
+	Watch               bool
+	WatchPollInterval   time.Duration
+	WatchSettleTime     time.Duration
 
 	enableTestConditions bool
 }
 
+// SMBCredentialProvider retrieves the credentials used to authenticate with a given host and share.  It is called
+// whenever a new connection to a server is made, so credentials don't need to be held for the life of the process.
+type SMBCredentialProvider = filesystem.SMBCredentialProvider
+
+type SMBCredentials = filesystem.SMBCredentials
+
+// SMBEncryption determines whether SMB traffic is encrypted.
+type SMBEncryption = filesystem.SMBEncryption
+
+// SMBProtocol identifies a version of the SMB protocol.
+type SMBProtocol = filesystem.SMBProtocol
+
+type SMBShare = filesystem.SMBShare
+
+// SMBShareType identifies the kind of resource an SMBShare provides.
+type SMBShareType = filesystem.SMBShareType
+
+// SMBSigning determines whether SMB traffic is signed.
+type SMBSigning = filesystem.SMBSigning
+
+// SMBTransport determines how an SMB Source connects to a server.
+type SMBTransport = filesystem.SMBTransport
+
+//
+// Public constants
+//
+
+const (
+	SMBAuthAnonymous = filesystem.SMBAuthAnonymous
+	SMBAuthGuest     = filesystem.SMBAuthGuest
+	SMBAuthKerberos  = filesystem.SMBAuthKerberos
+	SMBAuthPassword  = filesystem.SMBAuthPassword
+)
+
+const (
+	SMBEncryptionDefault = filesystem.SMBEncryptionDefault
+	SMBEncryptionNone    = filesystem.SMBEncryptionNone
+	SMBEncryptionRequest = filesystem.SMBEncryptionRequest
+	SMBEncryptionRequire = filesystem.SMBEncryptionRequire
+)
+
+const (
+	SMBProtocolDefault = filesystem.SMBProtocolDefault
+	SMBProtocolSMB2    = filesystem.SMBProtocolSMB2
+	SMBProtocolSMB3    = filesystem.SMBProtocolSMB3
+)
+
+const (
+	SMBShareTypeComms   = filesystem.SMBShareTypeComms
+	SMBShareTypeDisk    = filesystem.SMBShareTypeDisk
+	SMBShareTypeIPC     = filesystem.SMBShareTypeIPC
+	SMBShareTypePrinter = filesystem.SMBShareTypePrinter
+)
+
+const (
+	SMBSigningDefault    = filesystem.SMBSigningDefault
+	SMBSigningIfRequired = filesystem.SMBSigningIfRequired
+	SMBSigningOff        = filesystem.SMBSigningOff
+	SMBSigningRequired   = filesystem.SMBSigningRequired
+)
+
+const (
+	SMBTransportAuto    = filesystem.SMBTransportAuto
+	SMBTransportDirect  = filesystem.SMBTransportDirect
+	SMBTransportNetBIOS = filesystem.SMBTransportNetBIOS
+)
+
 //
 // Public functions
 //
@@ -31,24 +123,64 @@ func SMB(config SMBConfig) (pipewerx.Source, error) {
 	var err error
 	var fs pipewerx.Filesystem
 
-	fs, err = filesystem.SMB(filesystem.SMBConfig{
+	fs, err = filesystem.SMB(newFilesystemSMBConfig(config))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pipewerx.NewSource(pipewerx.SourceConfig{
+		BytesPerSecond:     config.BytesPerSecond,
+		CheckpointInterval: config.CheckpointInterval,
+		Checkpoints:        config.Checkpoints,
+		ErrorPolicy:        config.ErrorPolicy,
+		Hashes:             config.Hashes,
+		ID:                 config.ID,
+		ListingsPerSecond:  config.ListingsPerSecond,
+		Recurse:            config.Recurse,
+		Root:               config.Root,
+		Watch:              config.Watch,
+		WatchPollInterval:  config.WatchPollInterval,
+		WatchSettleTime:    config.WatchSettleTime,
+	}, fs)
+}
+
+// SMBServers lists the servers in the workgroup given by SMBConfig.Domain.  Only the authentication and connection
+// settings in the SMBConfig are used.
+func SMBServers(config SMBConfig) ([]string, error) {
+	return filesystem.SMBServers(newFilesystemSMBConfig(config))
+}
+
+// SMBShares lists the shares on the host given by SMBConfig.Host, which can be used to validate SMBConfig.Share or to
+// create a Source for each share.  Only the authentication and connection settings in the SMBConfig are used.
+func SMBShares(config SMBConfig) ([]SMBShare, error) {
+	return filesystem.SMBShares(newFilesystemSMBConfig(config))
+}
+
+//
+// Private functions
+//
+
+func newFilesystemSMBConfig(config SMBConfig) filesystem.SMBConfig {
+	return filesystem.SMBConfig{
+		Auth:                 config.Auth,
+		Credentials:          config.Credentials,
 		Domain:               config.Domain,
 		EnableTestConditions: config.enableTestConditions,
+		Encryption:           config.Encryption,
 		Host:                 config.Host,
+		MaxConnections:       config.MaxConnections,
+		MaxIdleTime:          config.MaxIdleTime,
+		MaxProtocol:          config.MaxProtocol,
+		MinProtocol:          config.MinProtocol,
 		Password:             config.Password,
 		Port:                 config.Port,
 		Root:                 config.Root,
+		SecurityDescriptors:  config.SecurityDescriptors,
 		Share:                config.Share,
+		Signing:              config.Signing,
+		Timeout:              config.Timeout,
+		Transport:            config.Transport,
 		Username:             config.Username,
-	})
-
-	if err != nil {
-		return nil, err
 	}
-
-	return pipewerx.NewSource(pipewerx.SourceConfig{
-		ID:      config.ID,
-		Recurse: config.Recurse,
-		Root:    config.Root,
-	}, fs)
 }
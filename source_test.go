@@ -326,6 +326,18 @@ var _ = g.Describe("NewSource", func() {
 			})
 		})
 
+		g.Context("with an unknown HashAlgorithm", func() {
+			g.It("should return an error", func() {
+				source, err = NewSource(SourceConfig{
+					Hashes: []HashAlgorithm{HashAlgorithmSHA256, HashAlgorithm(10)},
+					ID:     "source",
+				}, &memFilesystem{})
+
+				Expect(source).To(BeNil())
+				Expect(err).To(MatchError("unknown hash algorithm: unknown(10)"))
+			})
+		})
+
 		g.Context("with a nil Filesystem", func() {
 			g.BeforeEach(func() {
 				source, err = NewSource(SourceConfig{}, nil)
@@ -339,6 +351,30 @@ var _ = g.Describe("NewSource", func() {
 			})
 		})
 
+		g.Context("with a templated root path", func() {
+			g.It("should return an error if the Filesystem isn't a RootedFilesystem", func() {
+				source, err = NewSource(SourceConfig{
+					ID:   "source",
+					Root: "{{.tenant}}",
+				}, &memFilesystem{})
+
+				Expect(source).To(BeNil())
+				Expect(errors.Is(err, errSourceNotRooted)).To(BeTrue())
+			})
+
+			g.It("should return an error if the template is invalid", func() {
+				source, err = NewSource(SourceConfig{
+					ID:   "source",
+					Root: "{{.tenant",
+				}, &rootedMemFilesystem{
+					memFilesystem: &memFilesystem{},
+				})
+
+				Expect(source).To(BeNil())
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
 		g.Context("with a valid Filesystem", func() {
 			var sink *testEventSink
 
@@ -398,7 +434,8 @@ var _ = g.Describe("Source", func() {
 						Expect(results).To(HaveLen(1))
 						Expect(results[0].File()).To(BeNil())
 						Expect(results[0].Error()).NotTo(BeNil())
-						Expect(results[0].Error().Error()).To(Equal("absolutePath"))
+						Expect(results[0].Error().Error()).To(Equal(sink.id + ": stat: absolutePath"))
+						Expect(results[0].Error()).To(beAPathError(sink.id, OperationStat, "", ErrorCategoryFatal))
 
 						Expect(sink).To(haveTheseEvents(eventSourceCreated, eventSourceStarted,
 							eventSourceResultProduced, eventSourceFinished))
@@ -506,6 +543,121 @@ var _ = g.Describe("Source", func() {
 				})
 			})
 
+		g.Context("which has a templated root path", func() {
+			var fs *rootedMemFilesystem
+
+			g.JustBeforeEach(func() {
+				fs = &rootedMemFilesystem{
+					memFilesystem: &memFilesystem{
+						root: &memFilesystemNode{
+							children: map[string]*memFilesystemNode{
+								"tenant1": {
+									children: map[string]*memFilesystemNode{
+										"file1": {},
+									},
+								},
+								"tenant2": {
+									children: map[string]*memFilesystemNode{
+										"file2": {},
+									},
+								},
+							},
+						},
+					},
+				}
+
+				source, err = NewSource(SourceConfig{
+					ID:   sink.id,
+					Root: "/{{.tenant}}",
+				}, fs)
+
+				Expect(err).To(BeNil())
+				Expect(source).NotTo(BeNil())
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should use the expanded root path", func() {
+					var context = NewContext(ContextConfig{})
+					var in <-chan Result
+					var results []Result
+
+					context.Vars()["tenant"] = "tenant2"
+
+					in, _ = source.Files(context)
+
+					for res := range in {
+						results = append(results, res)
+					}
+
+					Expect(fs.roots).To(Equal([]string{"/tenant2"}))
+					Expect(results).To(HaveLen(1))
+					Expect(results[0].Error()).To(BeNil())
+					Expect(results[0].File().Path().String()).To(Equal("file2"))
+				})
+
+				g.It("should return an error if the root path can't be expanded", func() {
+					var results = collectSourceResults(source)
+
+					Expect(fs.roots).To(BeEmpty())
+					Expect(results).To(HaveLen(1))
+					Expect(results[0].Error()).To(beAPathError(sink.id, OperationStat, "/{{.tenant}}",
+						ErrorCategoryFatal))
+				})
+			})
+		})
+
+		g.Context("which fails immediately upon access and uses the collect error policy", func() {
+			g.JustBeforeEach(func() {
+				source, err = NewSource(SourceConfig{
+					ErrorPolicy: ErrorPolicyCollect,
+					ID:          sink.id,
+				}, &memFilesystem{
+					absolutePathError: errors.New("absolutePath"),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(source).NotTo(BeNil())
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should return a single Result containing the error", func() {
+					var multiErr MultiError
+					var ok bool
+					var results = collectSourceResults(source)
+
+					Expect(results).To(HaveLen(1))
+
+					multiErr, ok = results[0].Error().(MultiError)
+
+					Expect(ok).To(BeTrue())
+					Expect(multiErr.Causes()).To(HaveLen(1))
+					Expect(multiErr.Causes()[0]).To(beAPathError(sink.id, OperationStat, "", ErrorCategoryFatal))
+				})
+			})
+		})
+
+		g.Context("which fails immediately upon access and uses the skip error policy", func() {
+			g.JustBeforeEach(func() {
+				source, err = NewSource(SourceConfig{
+					ErrorPolicy: ErrorPolicySkip,
+					ID:          sink.id,
+				}, &memFilesystem{
+					absolutePathError: errors.New("absolutePath"),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(source).NotTo(BeNil())
+			})
+
+			g.Describe("calling Files", func() {
+				g.It("should return no Results", func() {
+					Expect(collectSourceResults(source)).To(HaveLen(0))
+
+					Expect(sink).To(haveTheseEvents(eventSourceCreated, eventSourceStarted, eventSourceFinished))
+				})
+			})
+		})
+
 		g.Context("which panics when calling Files", func() {
 			g.JustBeforeEach(func() {
 				source, err = NewSource(SourceConfig{ID: sink.id}, &memFilesystem{
@@ -526,7 +678,9 @@ var _ = g.Describe("Source", func() {
 					Expect(results).To(HaveLen(1))
 					Expect(results[0].File()).To(BeNil())
 					Expect(results[0].Error()).NotTo(BeNil())
-					Expect(results[0].Error().Error()).To(Equal("a fatal error occurred: absolutePath"))
+					Expect(results[0].Error().Error()).To(Equal(sink.id +
+						": stat: a fatal error occurred: absolutePath"))
+					Expect(results[0].Error()).To(beAPathError(sink.id, OperationStat, "", ErrorCategoryFatal))
 
 					Expect(sink).To(haveTheseEvents(eventSourceCreated, eventSourceStarted, eventSourceResultProduced,
 						eventSourceFinished))
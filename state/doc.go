@@ -0,0 +1 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
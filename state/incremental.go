@@ -0,0 +1,175 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
+
+import (
+	"errors"
+	"sync"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Public types
+//
+
+// IncrementalEvaluator is a FileEvaluator that only keeps Files that are new or have changed since they were last
+// committed to a Store.
+type IncrementalEvaluator interface {
+	// Commit records every File that has been evaluated since the last call to Commit() in the Store, so that they
+	// won't be kept again unless they change.  Commit should only be called once a run has completed successfully, so
+	// that Files are processed again by the next run if the current run fails.
+	Commit() error
+
+	pipewerx.FileEvaluator
+}
+
+type IncrementalEvaluatorConfig struct {
+	// Algorithm is used to compute digests if UseHash is true.  Configuring Sources to compute digests using the same
+	// HashAlgorithm (see pipewerx.SourceConfig.Hashes) avoids reading Files a second time when committing.
+	Algorithm pipewerx.HashAlgorithm
+
+	Store Store
+
+	// UseHash determines whether digests are recorded and used to determine whether a File whose modification time
+	// has changed has actually changed.  If UseHash is false, a File is considered to have changed if either its size
+	// or modification time has changed.
+	UseHash bool
+}
+
+//
+// Public functions
+//
+
+// NewIncrementalEvaluator creates an IncrementalEvaluator.  Note that destroying the IncrementalEvaluator does not
+// close its Store.
+func NewIncrementalEvaluator(config IncrementalEvaluatorConfig) (IncrementalEvaluator, error) {
+	if config.Store == nil {
+		return nil, errIncrementalNilStore
+	}
+
+	return &incrementalEvaluator{
+		config:  config,
+		pending: make(map[string]map[string]pendingFile),
+	}, nil
+}
+
+//
+// Private types
+//
+
+// IncrementalEvaluator implementation
+type incrementalEvaluator struct {
+	config  IncrementalEvaluatorConfig
+	mutex   sync.Mutex
+	pending map[string]map[string]pendingFile
+}
+
+func (evaluator *incrementalEvaluator) Commit() error {
+	evaluator.mutex.Lock()
+	defer evaluator.mutex.Unlock()
+
+	// Records are committed one Source at a time and only forgotten once committed, so Commit can be retried if it
+	// fails.
+
+	for sourceID, files := range evaluator.pending {
+		var err error
+		var records = make(map[string]Record, len(files))
+
+		for path, pendingFile := range files {
+			var record = pendingFile.record
+
+			if evaluator.config.UseHash && record.Hash == "" {
+				if record.Hash, err = pipewerx.Digest(pendingFile.file, evaluator.config.Algorithm); err != nil {
+					return err
+				}
+			}
+
+			records[path] = record
+		}
+
+		if err = evaluator.config.Store.Put(sourceID, records); err != nil {
+			return err
+		}
+
+		delete(evaluator.pending, sourceID)
+	}
+
+	return nil
+}
+
+func (evaluator *incrementalEvaluator) Destroy() error {
+	return nil
+}
+
+func (evaluator *incrementalEvaluator) ShouldKeep(file pipewerx.File) (bool, error) {
+	var digest string
+	var err error
+	var found bool
+	var previous Record
+	var record = Record{
+		ModTime: file.ModTime(),
+		Size:    file.Size(),
+	}
+
+	if previous, found, err = evaluator.config.Store.Get(file.SourceID(), file.Path().String()); err != nil {
+		return false, err
+	}
+
+	switch {
+	case !found || previous.Size != record.Size:
+		evaluator.addPending(file, record)
+
+		return true, nil
+
+	case previous.ModTime.Equal(record.ModTime):
+		return false, nil
+
+	case !evaluator.config.UseHash || previous.Hash == "":
+		evaluator.addPending(file, record)
+
+		return true, nil
+	}
+
+	// Only the modification time has changed, so compare digests to find out whether the contents have changed.  Either
+	// way, the new digest is recorded so that the File isn't read again by the next run.
+
+	if digest, err = pipewerx.Digest(file, evaluator.config.Algorithm); err != nil {
+		return false, err
+	}
+
+	record.Hash = digest
+
+	evaluator.addPending(file, record)
+
+	return digest != previous.Hash, nil
+}
+
+func (evaluator *incrementalEvaluator) addPending(file pipewerx.File, record Record) {
+	var files map[string]pendingFile
+	var ok bool
+
+	evaluator.mutex.Lock()
+	defer evaluator.mutex.Unlock()
+
+	if files, ok = evaluator.pending[file.SourceID()]; !ok {
+		files = make(map[string]pendingFile)
+
+		evaluator.pending[file.SourceID()] = files
+	}
+
+	files[file.Path().String()] = pendingFile{
+		file:   file,
+		record: record,
+	}
+}
+
+// Records a File that has been evaluated but not yet committed.
+type pendingFile struct {
+	file   pipewerx.File
+	record Record
+}
+
+//
+// Private variables
+//
+
+var errIncrementalNilStore = errors.New("cannot create IncrementalEvaluator using nil Store")
@@ -0,0 +1,280 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"golang.handcraftedbits.com/pipewerx"
+)
+
+//
+// Testcases
+//
+
+// IncrementalEvaluator tests
+
+var _ = Describe("IncrementalEvaluator", func() {
+	Describe("calling NewIncrementalEvaluator", func() {
+		Context("with a nil Store", func() {
+			It("should return an error", func() {
+				var err error
+				var evaluator IncrementalEvaluator
+
+				evaluator, err = NewIncrementalEvaluator(IncrementalEvaluatorConfig{})
+
+				Expect(err).To(MatchError(errIncrementalNilStore))
+				Expect(evaluator).To(BeNil())
+			})
+		})
+	})
+
+	Describe("given a new instance", func() {
+		var config IncrementalEvaluatorConfig
+		var dir string
+		var evaluator IncrementalEvaluator
+		var modTime = time.Date(2020, time.April, 1, 12, 0, 0, 0, time.UTC)
+
+		BeforeEach(func() {
+			var err error
+
+			dir, err = ioutil.TempDir("", "pipewerx")
+
+			Expect(err).To(BeNil())
+
+			config = IncrementalEvaluatorConfig{
+				Algorithm: pipewerx.HashAlgorithmSHA256,
+			}
+
+			config.Store, err = Open(filepath.Join(dir, "state.db"))
+
+			Expect(err).To(BeNil())
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			evaluator, err = NewIncrementalEvaluator(config)
+
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			Expect(evaluator.Destroy()).To(BeNil())
+			Expect(config.Store.Close()).To(BeNil())
+			Expect(os.RemoveAll(dir)).To(BeNil())
+		})
+
+		Context("which does not use hashes", func() {
+			It("should only keep new or changed files once a run has been committed", func() {
+				Expect(evaluator.ShouldKeep(newTestFile("source", "a", "abc", modTime))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newTestFile("source", "b", "abc", modTime))).To(BeTrue())
+
+				// Nothing has been committed, so the files are still new.
+
+				Expect(evaluator.ShouldKeep(newTestFile("source", "a", "abc", modTime))).To(BeTrue())
+
+				Expect(evaluator.Commit()).To(BeNil())
+
+				Expect(evaluator.ShouldKeep(newTestFile("source", "a", "abc", modTime))).To(BeFalse())
+				Expect(evaluator.ShouldKeep(newTestFile("other", "a", "abc", modTime))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newTestFile("source", "b", "abcd", modTime))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newTestFile("source", "b", "xyz", modTime.Add(time.Second)))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newTestFile("source", "c", "abc", modTime))).To(BeTrue())
+			})
+		})
+
+		Context("which uses hashes", func() {
+			BeforeEach(func() {
+				config.UseHash = true
+			})
+
+			It("should only keep files whose contents have changed", func() {
+				var touched = newTestFile("source", "a", "abc", modTime.Add(time.Second))
+
+				Expect(evaluator.ShouldKeep(newTestFile("source", "a", "abc", modTime))).To(BeTrue())
+				Expect(evaluator.ShouldKeep(newTestFile("source", "b", "abc", modTime))).To(BeTrue())
+				Expect(evaluator.Commit()).To(BeNil())
+
+				// Only the modification time has changed.
+
+				Expect(evaluator.ShouldKeep(touched)).To(BeFalse())
+				Expect(touched.reads).To(Equal(1))
+
+				// The contents have changed.
+
+				Expect(evaluator.ShouldKeep(newTestFile("source", "b", "xyz", modTime.Add(time.Second)))).To(BeTrue())
+				Expect(evaluator.Commit()).To(BeNil())
+
+				// The new modification time was recorded, so the file doesn't need to be read again.
+
+				touched = newTestFile("source", "a", "abc", modTime.Add(time.Second))
+
+				Expect(evaluator.ShouldKeep(touched)).To(BeFalse())
+				Expect(touched.reads).To(Equal(0))
+			})
+
+			Context("and a file that cannot be read", func() {
+				It("should return an error when committing and allow the commit to be retried", func() {
+					var f = newTestFile("source", "a", "abc", modTime)
+
+					f.readError = errors.New("read")
+
+					Expect(evaluator.ShouldKeep(f)).To(BeTrue())
+					Expect(evaluator.Commit()).To(MatchError("read"))
+
+					f.readError = nil
+
+					Expect(evaluator.Commit()).To(BeNil())
+					Expect(evaluator.ShouldKeep(newTestFile("source", "a", "abc", modTime))).To(BeFalse())
+				})
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// pipewerx.Attributes implementation
+type testAttributes struct {
+	mutex  sync.Mutex
+	values map[string]interface{}
+}
+
+func (attrs *testAttributes) Get(key string) (interface{}, bool) {
+	var value interface{}
+	var ok bool
+
+	attrs.mutex.Lock()
+	defer attrs.mutex.Unlock()
+
+	value, ok = attrs.values[key]
+
+	return value, ok
+}
+
+func (attrs *testAttributes) Keys() []string {
+	var keys []string
+
+	attrs.mutex.Lock()
+	defer attrs.mutex.Unlock()
+
+	for key := range attrs.values {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (attrs *testAttributes) Set(key string, value interface{}) {
+	attrs.mutex.Lock()
+	defer attrs.mutex.Unlock()
+
+	attrs.values[key] = value
+}
+
+// pipewerx.File implementation
+type testFile struct {
+	attributes *testAttributes
+	contents   string
+	modTime    time.Time
+	name       string
+	readError  error
+	reads      int
+	sourceID   string
+}
+
+func (f *testFile) Attributes() pipewerx.Attributes {
+	return f.attributes
+}
+
+func (f *testFile) IsDir() bool {
+	return false
+}
+
+func (f *testFile) Mode() os.FileMode {
+	return os.ModePerm
+}
+
+func (f *testFile) ModTime() time.Time {
+	return f.modTime
+}
+
+func (f *testFile) Name() string {
+	return f.name
+}
+
+func (f *testFile) Path() pipewerx.FilePath {
+	return &testFilePath{
+		name: f.name,
+	}
+}
+
+func (f *testFile) Reader() (io.ReadCloser, error) {
+	f.reads++
+
+	if f.readError != nil {
+		return nil, f.readError
+	}
+
+	return ioutil.NopCloser(bytes.NewBufferString(f.contents)), nil
+}
+
+func (f *testFile) Size() int64 {
+	return int64(len(f.contents))
+}
+
+func (f *testFile) SourceID() string {
+	return f.sourceID
+}
+
+func (f *testFile) Sys() interface{} {
+	return nil
+}
+
+// pipewerx.FilePath implementation
+type testFilePath struct {
+	name string
+}
+
+func (path *testFilePath) Dir() []string {
+	return []string{}
+}
+
+func (path *testFilePath) Extension() string {
+	return ""
+}
+
+func (path *testFilePath) Name() string {
+	return path.name
+}
+
+func (path *testFilePath) String() string {
+	return path.name
+}
+
+//
+// Private functions
+//
+
+func newTestFile(sourceID, name, contents string, modTime time.Time) *testFile {
+	return &testFile{
+		attributes: &testAttributes{
+			values: make(map[string]interface{}),
+		},
+		contents: contents,
+		modTime:  modTime,
+		name:     name,
+		sourceID: sourceID,
+	}
+}
@@ -0,0 +1,122 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+//
+// Public types
+//
+
+// Record describes a file as it was when it was last processed.
+type Record struct {
+	// Hash is the hex-encoded digest of the file's contents.  It is empty if no digest was computed.
+	Hash string `json:"hash,omitempty"`
+
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+}
+
+// Store persists Records keyed by Source ID and file path.  Stores are safe for concurrent use.
+type Store interface {
+	Close() error
+
+	// Get retrieves the Record for a file, returning false if no Record exists.
+	Get(sourceID, path string) (Record, bool, error)
+
+	// Put stores Records for multiple files produced by a single Source at once, such that either all or none of the
+	// Records are stored.
+	Put(sourceID string, records map[string]Record) error
+}
+
+//
+// Public functions
+//
+
+// Open opens the Store contained in a local file, creating the file if it doesn't exist.  Note that only one process
+// can have a Store open at a time; Open blocks until the Store is available or a minute has passed.
+func Open(path string) (Store, error) {
+	var db *bbolt.DB
+	var err error
+
+	db, err = bbolt.Open(path, 0600, &bbolt.Options{
+		Timeout: time.Minute,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStore{
+		db: db,
+	}, nil
+}
+
+//
+// Private types
+//
+
+// Store implementation backed by BoltDB, using one bucket per Source.
+type boltStore struct {
+	db *bbolt.DB
+}
+
+func (store *boltStore) Close() error {
+	return store.db.Close()
+}
+
+func (store *boltStore) Get(sourceID, path string) (Record, bool, error) {
+	var err error
+	var found bool
+	var record Record
+
+	err = store.db.View(func(tx *bbolt.Tx) error {
+		var bucket = tx.Bucket([]byte(sourceID))
+		var value []byte
+
+		if bucket == nil {
+			return nil
+		}
+
+		if value = bucket.Get([]byte(path)); value == nil {
+			return nil
+		}
+
+		found = true
+
+		return json.Unmarshal(value, &record)
+	})
+
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	return record, found, nil
+}
+
+func (store *boltStore) Put(sourceID string, records map[string]Record) error {
+	return store.db.Update(func(tx *bbolt.Tx) error {
+		var bucket, err = tx.CreateBucketIfNotExists([]byte(sourceID))
+
+		if err != nil {
+			return err
+		}
+
+		for path, record := range records {
+			var value []byte
+
+			if value, err = json.Marshal(record); err != nil {
+				return err
+			}
+
+			if err = bucket.Put([]byte(path), value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,110 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// Store tests
+
+var _ = Describe("Store", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+
+		dir, err = ioutil.TempDir("", "pipewerx")
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(BeNil())
+	})
+
+	Describe("calling Open", func() {
+		Context("with a path that cannot be created", func() {
+			It("should return an error", func() {
+				var err error
+				var store Store
+
+				store, err = Open(filepath.Join(dir, "missing", "state.db"))
+
+				Expect(err).NotTo(BeNil())
+				Expect(store).To(BeNil())
+			})
+		})
+	})
+
+	Describe("given a new instance", func() {
+		var store Store
+
+		BeforeEach(func() {
+			var err error
+
+			store, err = Open(filepath.Join(dir, "state.db"))
+
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			Expect(store.Close()).To(BeNil())
+		})
+
+		Describe("calling Get", func() {
+			Context("for a Source that has no Records", func() {
+				It("should return false", func() {
+					var err error
+					var found bool
+
+					_, found, err = store.Get("source", "path")
+
+					Expect(err).To(BeNil())
+					Expect(found).To(BeFalse())
+				})
+			})
+		})
+
+		Describe("calling Put", func() {
+			It("should store Records that persist after the Store is reopened", func() {
+				var err error
+				var found bool
+				var modTime = time.Date(2020, time.April, 1, 12, 0, 0, 1, time.UTC)
+				var record Record
+
+				Expect(store.Put("source", map[string]Record{
+					"a": {Hash: "hash", ModTime: modTime, Size: 3},
+					"b": {ModTime: modTime, Size: 4},
+				})).To(BeNil())
+
+				Expect(store.Close()).To(BeNil())
+
+				store, err = Open(filepath.Join(dir, "state.db"))
+
+				Expect(err).To(BeNil())
+
+				record, found, err = store.Get("source", "a")
+
+				Expect(err).To(BeNil())
+				Expect(found).To(BeTrue())
+				Expect(record.Hash).To(Equal("hash"))
+				Expect(record.ModTime.Equal(modTime)).To(BeTrue())
+				Expect(record.Size).To(BeEquivalentTo(3))
+
+				_, found, err = store.Get("other", "a")
+
+				Expect(err).To(BeNil())
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,18 @@
+package state // import "golang.handcraftedbits.com/pipewerx/state"
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+func TestSuiteState(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "state")
+}
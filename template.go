@@ -0,0 +1,126 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//
+// Public types
+//
+
+// TemplateFile exposes the properties of a File to templates (see ExpandTemplate()).
+type TemplateFile struct {
+	// Base is the name of the File, including its extension.
+	Base string
+
+	Dir []string
+
+	// Ext is the extension of the File, without the leading ".".
+	Ext string
+
+	ModTime time.Time
+
+	// Name is the name of the File, without its extension.
+	Name string
+
+	Path     string
+	Size     int64
+	SourceID string
+}
+
+//
+// Public constants
+//
+
+// The names of the values that are always available to templates, in addition to those in Context.Vars().  A value in
+// Context.Vars() with the same name takes precedence.
+const (
+	// TemplateVarDate holds the current date, in the format 2006-01-02.
+	TemplateVarDate = "date"
+
+	// TemplateVarFile holds a TemplateFile describing the File being processed, if there is one.
+	TemplateVarFile = "file"
+
+	// TemplateVarNow holds the current time.
+	TemplateVarNow = "now"
+)
+
+//
+// Public functions
+//
+
+// ExpandTemplate expands a text/template using the values in Context.Vars() and, if file isn't nil, the properties of
+// the File (e.g., "{{.date}}/{{.tenant}}/{{.file.Name}}.{{.file.Ext}}").  The env function can be used to retrieve
+// environment variables (e.g., "{{env "HOME"}}").  Referring to a value that doesn't exist is an error, so that a
+// missing variable doesn't silently produce the wrong path.
+func ExpandTemplate(text string, context Context, file File) (string, error) {
+	var err error
+	var tmpl *template.Template
+
+	if tmpl, err = newTemplate(text); err != nil {
+		return "", err
+	}
+
+	return executeTemplate(tmpl, context, file)
+}
+
+//
+// Private constants
+//
+
+const templateDateFormat = "2006-01-02"
+
+//
+// Private functions
+//
+
+func executeTemplate(tmpl *template.Template, context Context, file File) (string, error) {
+	var builder strings.Builder
+	var data = make(map[string]interface{})
+	var now = time.Now()
+
+	data[TemplateVarDate] = now.Format(templateDateFormat)
+	data[TemplateVarNow] = now
+
+	if file != nil {
+		data[TemplateVarFile] = TemplateFile{
+			Base:     file.Name(),
+			Dir:      file.Path().Dir(),
+			Ext:      file.Path().Extension(),
+			ModTime:  file.ModTime(),
+			Name:     file.Path().Name(),
+			Path:     file.Path().String(),
+			Size:     file.Size(),
+			SourceID: file.SourceID(),
+		}
+	}
+
+	if context != nil {
+		for key, value := range context.Vars() {
+			data[key] = value
+		}
+	}
+
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// Determines whether a string contains any template actions, so that plain strings can bypass template processing.
+func isTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("").
+		Funcs(template.FuncMap{
+			"env": os.Getenv,
+		}).
+		Option("missingkey=error").
+		Parse(text)
+}
@@ -0,0 +1,110 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"os"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// ExpandTemplate tests
+
+var _ = g.Describe("ExpandTemplate", func() {
+	var context Context
+
+	g.BeforeEach(func() {
+		context = NewContext(ContextConfig{})
+	})
+
+	g.It("should expand values from Context.Vars", func() {
+		var err error
+		var expanded string
+
+		context.Vars()["tenant"] = "tenant1"
+
+		expanded, err = ExpandTemplate("/data/{{.tenant}}", context, nil)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal("/data/tenant1"))
+	})
+
+	g.It("should expand the current date unless Context.Vars overrides it", func() {
+		var err error
+		var expanded string
+
+		expanded, err = ExpandTemplate("{{.date}}", context, nil)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal(time.Now().Format("2006-01-02")))
+
+		context.Vars()[TemplateVarDate] = "2020-01-02"
+
+		expanded, err = ExpandTemplate("{{.date}}", context, nil)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal("2020-01-02"))
+	})
+
+	g.It("should expand environment variables", func() {
+		var err error
+		var expanded string
+
+		Expect(os.Setenv("PIPEWERX_TEMPLATE_TEST", "value")).To(BeNil())
+
+		defer os.Unsetenv("PIPEWERX_TEMPLATE_TEST")
+
+		expanded, err = ExpandTemplate(`{{env "PIPEWERX_TEMPLATE_TEST"}}`, context, nil)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal("value"))
+	})
+
+	g.It("should expand the properties of a File", func() {
+		var err error
+		var expanded string
+		var f = &file{
+			fileInfo: &nilFileInfo{
+				name: "name.ext",
+				size: 3,
+			},
+			path:     newFilePath([]string{"dir"}, "name.ext", "/"),
+			sourceID: "source",
+		}
+
+		expanded, err = ExpandTemplate(
+			"{{.file.SourceID}}/{{index .file.Dir 0}}/{{.file.Name}}-{{.file.Size}}.{{.file.Ext}}", context, f)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal("source/dir/name-3.ext"))
+
+		expanded, err = ExpandTemplate("{{.file.Path}} {{.file.Base}}", context, f)
+
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal("dir/name.ext name.ext"))
+	})
+
+	g.It("should return an error if a value doesn't exist", func() {
+		var err error
+
+		_, err = ExpandTemplate("{{.missing}}", context, nil)
+
+		Expect(err).NotTo(BeNil())
+
+		_, err = ExpandTemplate("{{.file.Ext}}", context, nil)
+
+		Expect(err).NotTo(BeNil())
+	})
+
+	g.It("should return an error if the template is invalid", func() {
+		var err error
+
+		_, err = ExpandTemplate("{{.date", context, nil)
+
+		Expect(err).NotTo(BeNil())
+	})
+})
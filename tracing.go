@@ -0,0 +1,74 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	gocontext "context"
+
+	"go.opentelemetry.io/otel/api/core"
+	"go.opentelemetry.io/otel/api/key"
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+//
+// Public constants
+//
+
+// Tracing span attribute keys.
+const (
+	AttributeKeyFilterID = "pipewerx.filter.id"
+	AttributeKeyPath     = "pipewerx.path"
+	AttributeKeySourceID = "pipewerx.source.id"
+)
+
+// Tracing span names.
+const (
+	SpanFileReader       = "pipewerx.File.Reader"
+	SpanFilterFiles      = "pipewerx.Filter.Files"
+	SpanFilterShouldKeep = "pipewerx.FileEvaluator.ShouldKeep"
+	SpanSourceFiles      = "pipewerx.Source.Files"
+)
+
+//
+// Private functions
+//
+
+// Starts a span that is a child of the span (if any) contained in a Context, returning a Context that contains the new
+// span.  The returned Context shares everything else with the given one, which is returned as is if tracing is
+// disabled.
+func startSpan(context Context, name string, attributes ...core.KeyValue) (Context, trace.Span) {
+	var span trace.Span
+	var traceContext gocontext.Context
+	var tracer = tracerOf(context)
+
+	traceContext, span = startSpanFromTraceContext(tracer, traceContextOf(context), name, attributes...)
+
+	if _, ok := tracer.(trace.NoopTracer); ok {
+		return context, span
+	}
+
+	return WithTraceContext(context, traceContext), span
+}
+
+func startSpanFromTraceContext(tracer trace.Tracer, traceContext gocontext.Context, name string,
+	attributes ...core.KeyValue) (gocontext.Context, trace.Span) {
+	if tracer == nil {
+		tracer = trace.NoopTracer{}
+	}
+
+	if traceContext == nil {
+		traceContext = gocontext.Background()
+	}
+
+	return tracer.Start(traceContext, name, trace.WithAttributes(attributes...))
+}
+
+func traceAttributeFilterID(id string) core.KeyValue {
+	return key.String(AttributeKeyFilterID, id)
+}
+
+func traceAttributePath(path string) core.KeyValue {
+	return key.String(AttributeKeyPath, path)
+}
+
+func traceAttributeSourceID(id string) core.KeyValue {
+	return key.String(AttributeKeySourceID, id)
+}
@@ -0,0 +1,120 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	gocontext "context"
+	"io"
+	"io/ioutil"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/api/core"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/api/trace/testtrace"
+)
+
+//
+// Testcases
+//
+
+// Tracing tests
+
+var _ = g.Describe("Tracing", func() {
+	g.Describe("given a Filter and Source that use a Context with a Tracer", func() {
+		var context Context
+		var filter Filter
+		var parent trace.Span
+		var tracer *testtrace.Tracer
+
+		g.BeforeEach(func() {
+			var err error
+			var source Source
+			var traceContext gocontext.Context
+
+			tracer = testtrace.NewTracer()
+
+			traceContext, parent = tracer.Start(gocontext.Background(), "parent")
+
+			context = NewContext(ContextConfig{
+				TraceContext: traceContext,
+				Tracer:       tracer,
+			})
+
+			source, err = NewSource(SourceConfig{ID: "source"}, &memFilesystem{
+				root: &memFilesystemNode{
+					children: map[string]*memFilesystemNode{
+						"file1.keep": {
+							contents: "abc",
+						},
+						"file2.nokeep": {},
+					},
+				},
+			})
+
+			Expect(err).To(BeNil())
+
+			filter, err = NewFilter(FilterConfig{ID: "filter"}, []Source{source}, &extensionFileEvaluator{
+				extension: "keep",
+			})
+
+			Expect(err).To(BeNil())
+		})
+
+		g.Describe("calling Files and reading every file", func() {
+			g.It("should produce the expected spans", func() {
+				var contents []byte
+				var err error
+				var in <-chan Result
+				var reader io.ReadCloser
+				var spans = make(map[string][]*testtrace.Span)
+
+				in, _ = filter.Files(context)
+
+				for result := range in {
+					Expect(result.Error()).To(BeNil())
+
+					reader, err = result.File().Reader()
+
+					Expect(err).To(BeNil())
+
+					contents, err = ioutil.ReadAll(reader)
+
+					Expect(err).To(BeNil())
+					Expect(string(contents)).To(Equal("abc"))
+					Expect(reader.Close()).To(BeNil())
+				}
+
+				parent.End()
+
+				for _, span := range tracer.Spans() {
+					Expect(span.Ended()).To(BeTrue())
+
+					spans[span.Name()] = append(spans[span.Name()], span)
+				}
+
+				Expect(spans[SpanFilterFiles]).To(HaveLen(1))
+				Expect(spans[SpanFilterFiles][0].ParentSpanID()).To(Equal(parent.SpanContext().SpanID))
+				Expect(spans[SpanFilterFiles][0].Attributes()).To(HaveKeyWithValue(core.Key(AttributeKeyFilterID),
+					core.String("filter")))
+
+				Expect(spans[SpanSourceFiles]).To(HaveLen(1))
+				Expect(spans[SpanSourceFiles][0].ParentSpanID()).To(Equal(
+					spans[SpanFilterFiles][0].SpanContext().SpanID))
+				Expect(spans[SpanSourceFiles][0].Attributes()).To(HaveKeyWithValue(core.Key(AttributeKeySourceID),
+					core.String("source")))
+
+				Expect(spans[SpanFilterShouldKeep]).To(HaveLen(2))
+
+				for _, span := range spans[SpanFilterShouldKeep] {
+					Expect(span.ParentSpanID()).To(Equal(spans[SpanFilterFiles][0].SpanContext().SpanID))
+					Expect(span.Attributes()).To(HaveKey(core.Key(AttributeKeyPath)))
+				}
+
+				Expect(spans[SpanFileReader]).To(HaveLen(1))
+				Expect(spans[SpanFileReader][0].ParentSpanID()).To(Equal(
+					spans[SpanSourceFiles][0].SpanContext().SpanID))
+				Expect(spans[SpanFileReader][0].Attributes()).To(HaveKeyWithValue(core.Key(AttributeKeyPath),
+					core.String("file1.keep")))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package pipewerx // import "golang.handcraftedbits.com/pipewerx"
 
 import (
+	gocontext "context"
 	"fmt"
 	"os"
 	"regexp"
@@ -8,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
 )
 
 //
@@ -26,6 +28,8 @@ type cancellationHelper struct {
 	callback  func()
 	cancel    chan<- struct{}
 	cancelled bool
+	component string
+	id        string
 	logger    *zerolog.Logger
 	mutex     sync.Mutex
 	out       chan<- Result
@@ -93,35 +97,157 @@ func (helper *cancellationHelper) invoker() CancelFunc {
 	}
 }
 
-// TODO: replace with log events when ready.
+// Uses the same fields as LogSink so that panics can be correlated with the events of the component that caused them.
 func (helper *cancellationHelper) logPanic(value interface{}) {
 	helper.logger.Warn().
+		Str("component", helper.component).
+		Str("id", helper.id).
 		Interface("error", value).
 		Msg("an unexpected error occurred during cancellation")
 }
 
+// errorPolicyHelper is used to apply an ErrorPolicy to the error Results encountered by Sources and Filters.
+type errorPolicyHelper struct {
+	collected []error
+	id        string
+	logger    *zerolog.Logger
+	policy    ErrorPolicy
+}
+
+// Returns the Result, if any, that should be sent downstream in place of an error Result, along with whether or not
+// processing should stop once that Result has been sent.
+func (helper *errorPolicyHelper) handle(res Result) (Result, bool) {
+	switch helper.policy {
+	case ErrorPolicyCollect:
+		helper.collected = append(helper.collected, res.Error())
+
+		return nil, false
+
+	case ErrorPolicyFailFast:
+		return res, true
+
+	case ErrorPolicySkip:
+		helper.logger.Warn().
+			Str("id", helper.id).
+			Err(res.Error()).
+			Msg("discarding an error Result")
+
+		return nil, false
+	}
+
+	return res, false
+}
+
+// Returns an error Result containing a MultiError that lists every collected error, or nil if no errors were collected.
+// The collected errors are then forgotten, so that a Source that never finishes can report each error only once.
+func (helper *errorPolicyHelper) finish() Result {
+	var collected = helper.collected
+	var message strings.Builder
+
+	if len(collected) == 0 {
+		return nil
+	}
+
+	helper.collected = nil
+
+	_, _ = fmt.Fprintf(&message, "'%s' finished with %d error(s):", helper.id, len(collected))
+
+	for _, err := range collected {
+		message.WriteString("\n\t" + err.Error())
+	}
+
+	return &result{
+		err: newMultiError(message.String(), collected),
+	}
+}
+
 // pathStepper is used to "step" through a filesystem path by listing one file at a time.
 type pathStepper struct {
-	dirs  *stringStack
-	files *stepperFileStack
-	fs    Filesystem
-	root  string
+	current     *stepperFile
+	dirs        *stringStack
+	files       *stepperFileStack
+	fs          Filesystem
+	listLimiter *rate.Limiter
+	root        string
+	unlisted    string
+	waitContext gocontext.Context
+}
+
+// Creates a Checkpoint that can be used to resume stepping after the most recently returned file.  The Source that uses
+// this pathStepper is responsible for filling in the SourceConfig details.
+func (stepper *pathStepper) checkpoint() *Checkpoint {
+	var checkpoint = &Checkpoint{
+		Base:  stepper.root,
+		Dirs:  append([]string{}, *stepper.dirs...),
+		Files: make([]string, 0, len(*stepper.files)),
+	}
+
+	for _, pending := range *stepper.files {
+		checkpoint.Files = append(checkpoint.Files, pending.path)
+	}
+
+	if stepper.current != nil {
+		checkpoint.LastPath = stepper.current.path
+	}
+
+	return checkpoint
 }
 
 func (stepper *pathStepper) nextFile() (*file, error) {
 	var curFile *stepperFile
+	var err error
+	var fileInfo os.FileInfo
+
+	stepper.unlisted = ""
+
+	for curFile == nil {
+		for stepper.files.isEmpty() {
+			var dir string
 
-	for stepper.files.isEmpty() {
-		if stepper.dirs.isEmpty() {
-			return nil, nil
+			if stepper.dirs.isEmpty() {
+				return nil, nil
+			}
+
+			// Wait before popping the directory, so that it's still included in any Checkpoint if waiting is
+			// interrupted.
+
+			if err = waitForTokens(stepper.waitContext, stepper.listLimiter, 1); err != nil {
+				return nil, err
+			}
+
+			dir = stepper.dirs.pop()
+
+			if err = findFiles(stepper.fs, dir, stepper.dirs, stepper.files); err != nil {
+				stepper.unlisted = dir
+
+				return nil, err
+			}
 		}
 
-		if err := findFiles(stepper.fs, stepper.dirs.pop(), stepper.dirs, stepper.files); err != nil {
-			return nil, err
+		curFile = stepper.files.pop()
+
+		if curFile.fileInfo == nil {
+			// Files restored from a Checkpoint only have paths, and may have been removed since the Checkpoint was
+			// created.
+
+			if fileInfo, err = statFile(stepper.fs, curFile.path); err != nil {
+				if categorize(err) == ErrorCategoryNotFound {
+					curFile = nil
+
+					continue
+				}
+
+				return nil, err
+			}
+
+			curFile = &stepperFile{
+				fileInfo: fileInfo,
+				path:     curFile.path,
+			}
 		}
 	}
 
-	curFile = stepper.files.pop()
+	stepper.current = curFile
 
 	return &file{
 		fileInfo: curFile.fileInfo,
@@ -130,6 +256,26 @@ func (stepper *pathStepper) nextFile() (*file, error) {
 	}, nil
 }
 
+// Returns the directory that the most recent call to nextFile() failed to list to the pathStepper, so that listing it
+// will be retried by the next call to nextFile() and it will be included in any Checkpoint.
+func (stepper *pathStepper) relist() {
+	if stepper.unlisted != "" {
+		stepper.dirs.push(stepper.unlisted)
+
+		stepper.unlisted = ""
+	}
+}
+
+// Returns the most recently returned file to the pathStepper, so that it will be returned again by the next call to
+// nextFile() and included in any Checkpoint.
+func (stepper *pathStepper) unread() {
+	if stepper.current != nil {
+		stepper.files.push(stepper.current)
+
+		stepper.current = nil
+	}
+}
+
 // stepperFile is used to capture information about a file encountered by a pathStepper.
 type stepperFile struct {
 	fileInfo os.FileInfo
@@ -202,22 +348,39 @@ var idRegexp = regexp.MustCompile("^[[:alnum:]]+(?:\\.[[:alnum:]]+)*$")
 // Private functions
 //
 
+func absolutePath(fs Filesystem, path string) (absPath string, e error) {
+	var err error
+
+	defer func() {
+		if value := recover(); value != nil {
+			e = newPathError("", OperationStat, path, newPanicError(value))
+		}
+	}()
+
+	if absPath, err = fs.AbsolutePath(path); err != nil {
+		return "", newPathError("", OperationStat, path, err)
+	}
+
+	return absPath, nil
+}
+
 // Finds all files and directories within the current path, without diving into subdirectories.
 func findFiles(fs Filesystem, path string, dirs *stringStack, files *stepperFileStack) (e error) {
 	var err error
 	var fileInfo os.FileInfo
 	var fileInfos []os.FileInfo
+	var operation = OperationStat
 
 	defer func() {
 		if value := recover(); value != nil {
-			e = newPanicError(value)
+			e = newPathError("", operation, path, newPanicError(value))
 		}
 	}()
 
 	fileInfo, err = fs.StatFile(path)
 
 	if err != nil {
-		return err
+		return newPathError("", operation, path, err)
 	}
 
 	if !fileInfo.IsDir() {
@@ -229,10 +392,12 @@ func findFiles(fs Filesystem, path string, dirs *stringStack, files *stepperFile
 		return nil
 	}
 
+	operation = OperationList
+
 	fileInfos, err = fs.ListFiles(path)
 
 	if err != nil {
-		return err
+		return newPathError("", operation, path, err)
 	}
 
 	for _, fileInfo = range fileInfos {
@@ -260,14 +425,43 @@ func findFiles(fs Filesystem, path string, dirs *stringStack, files *stepperFile
 	return nil
 }
 
-func newCancellationHelper(logger *zerolog.Logger, out chan<- Result, cancel chan<- struct{},
+// Creates a pathStepper that resumes stepping from a Checkpoint.
+func newPathStepperFromCheckpoint(fs Filesystem, checkpoint *Checkpoint) *pathStepper {
+	var dirs = stringStack(append([]string{}, checkpoint.Dirs...))
+	var files = make(stepperFileStack, 0, len(checkpoint.Files))
+
+	for _, path := range checkpoint.Files {
+		files.push(&stepperFile{
+			path: path,
+		})
+	}
+
+	return &pathStepper{
+		dirs:  &dirs,
+		files: &files,
+		fs:    fs,
+		root:  checkpoint.Base,
+	}
+}
+
+func newCancellationHelper(logger *zerolog.Logger, component, id string, out chan<- Result, cancel chan<- struct{},
 	wg *sync.WaitGroup) *cancellationHelper {
 	return &cancellationHelper{
-		cancel: cancel,
+		cancel:    cancel,
+		component: component,
+		id:        id,
+		logger:    logger,
+		mutex:     sync.Mutex{},
+		out:       out,
+		wg:        wg,
+	}
+}
+
+func newErrorPolicyHelper(logger *zerolog.Logger, id string, policy ErrorPolicy) *errorPolicyHelper {
+	return &errorPolicyHelper{
+		id:     id,
 		logger: logger,
-		mutex:  sync.Mutex{},
-		out:    out,
-		wg:     wg,
+		policy: policy,
 	}
 }
 
@@ -284,14 +478,12 @@ func newPathStepper(fs Filesystem, root string, recurse bool) (p *pathStepper, e
 
 	defer func() {
 		if value := recover(); value != nil {
-			e = newPanicError(value)
+			e = newPathError("", OperationStat, root, newPanicError(value))
 			p = nil
 		}
 	}()
 
-	root, err = fs.AbsolutePath(root)
-
-	if err != nil {
+	if root, err = absolutePath(fs, root); err != nil {
 		return nil, err
 	}
 
@@ -330,6 +522,39 @@ func stripRoot(root, path, separator string) string {
 	return path
 }
 
+// Retrieves information for a single file, guarding against panics.
+func statFile(fs Filesystem, path string) (fileInfo os.FileInfo, e error) {
+	var err error
+
+	defer func() {
+		if value := recover(); value != nil {
+			e = newPathError("", OperationStat, path, newPanicError(value))
+		}
+	}()
+
+	if fileInfo, err = fs.StatFile(path); err != nil {
+		return nil, newPathError("", OperationStat, path, err)
+	}
+
+	return fileInfo, nil
+}
+
+// Creates a token bucket rate limiter that allows a given number of events per second, with a burst size of one
+// second's worth of events.  A nil *rate.Limiter, which never limits events, is returned if eventsPerSecond is zero.
+func newRateLimiter(eventsPerSecond float64) *rate.Limiter {
+	var burst = int(eventsPerSecond)
+
+	if eventsPerSecond <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(eventsPerSecond), burst)
+}
+
 func validateID(id string) error {
 	if idRegexp.MatchString(id) {
 		return nil
@@ -337,3 +562,27 @@ func validateID(id string) error {
 
 	return fmt.Errorf("invalid ID '%s'", id)
 }
+
+// Waits until a rate limiter allows a number of events, which may be larger than its burst size.  A nil *rate.Limiter
+// never waits.
+func waitForTokens(waitContext gocontext.Context, limiter *rate.Limiter, count int) error {
+	if limiter == nil {
+		return nil
+	}
+
+	for count > 0 {
+		var amount = count
+
+		if amount > limiter.Burst() {
+			amount = limiter.Burst()
+		}
+
+		if err := limiter.WaitN(waitContext, amount); err != nil {
+			return err
+		}
+
+		count -= amount
+	}
+
+	return nil
+}
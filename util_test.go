@@ -30,13 +30,14 @@ var _ = g.Describe("cancellationHelper", func() {
 		g.Describe("calling finalize", func() {
 			g.Context("with a panic occurring inside finalize", func() {
 				g.JustBeforeEach(func() {
-					helper = newCancellationHelper(context.Log(), nil, nil, nil)
+					helper = newCancellationHelper(context.Log(), componentSource, "source", nil, nil, nil)
 				})
 
 				g.It("should log a warning", func() {
 					helper.finalize()
 
 					Expect(buffer.String()).To(ContainSubstring("an unexpected error occurred during cancellation"))
+					Expect(buffer.String()).To(MatchRegexp(`id=\S*source`))
 				})
 
 				g.It("should still call the cancellation callback", func() {
@@ -55,7 +56,8 @@ var _ = g.Describe("cancellationHelper", func() {
 
 			g.Context("with a panic occurring inside the invoker's callback function", func() {
 				g.JustBeforeEach(func() {
-					helper = newCancellationHelper(context.Log(), make(chan Result), make(chan<- struct{}), nil)
+					helper = newCancellationHelper(context.Log(), componentSource, "source", make(chan Result),
+						make(chan<- struct{}), nil)
 				})
 
 				g.It("should log a warning", func() {
@@ -72,6 +74,46 @@ var _ = g.Describe("cancellationHelper", func() {
 	})
 })
 
+// errorPolicyHelper tests
+
+var _ = g.Describe("errorPolicyHelper", func() {
+	g.Describe("given a new instance that collects errors", func() {
+		var helper *errorPolicyHelper
+
+		g.BeforeEach(func() {
+			helper = newErrorPolicyHelper(NewContext(ContextConfig{}).Log(), "source", ErrorPolicyCollect)
+		})
+
+		g.Describe("calling finish", func() {
+			g.It("should only report each collected error once", func() {
+				var multiErr MultiError
+				var res Result
+
+				helper.handle(&result{
+					err: errors.New("first"),
+				})
+
+				res = helper.finish()
+
+				Expect(res).NotTo(BeNil())
+				Expect(errors.As(res.Error(), &multiErr)).To(BeTrue())
+				Expect(multiErr.Causes()).To(HaveLen(1))
+				Expect(helper.finish()).To(BeNil())
+
+				helper.handle(&result{
+					err: errors.New("second"),
+				})
+
+				res = helper.finish()
+
+				Expect(res).NotTo(BeNil())
+				Expect(errors.As(res.Error(), &multiErr)).To(BeTrue())
+				Expect(multiErr.Causes()).To(ConsistOf(MatchError("second")))
+			})
+		})
+	})
+})
+
 // pathStepper tests
 
 var _ = g.Describe("pathStepper", func() {
@@ -315,7 +357,8 @@ var _ = g.Describe("findFiles", func() {
 				Expect(err).ToNot(BeNil())
 				Expect(stepper).To(BeNil())
 
-				Expect(err.Error()).To(Equal("statFile"))
+				Expect(err.Error()).To(Equal("stat /: statFile"))
+				Expect(err).To(beAPathError("", OperationStat, "/", ErrorCategoryFatal))
 			})
 		})
 
@@ -344,7 +387,8 @@ var _ = g.Describe("findFiles", func() {
 				Expect(err).ToNot(BeNil())
 				Expect(file).To(BeNil())
 
-				Expect(err.Error()).To(Equal("listFiles"))
+				Expect(err.Error()).To(Equal("list /dir: listFiles"))
+				Expect(err).To(beAPathError("", OperationList, "/dir", ErrorCategoryFatal))
 			})
 		})
 
@@ -363,7 +407,8 @@ var _ = g.Describe("findFiles", func() {
 				err = findFiles(fs, "/", &stringStack{}, &stepperFileStack{})
 
 				Expect(err).ToNot(BeNil())
-				Expect(err.Error()).To(Equal("a fatal error occurred: statFile"))
+				Expect(err.Error()).To(Equal("stat /: a fatal error occurred: statFile"))
+				Expect(err).To(beAPathError("", OperationStat, "/", ErrorCategoryFatal))
 			})
 		})
 	})
@@ -0,0 +1,274 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	gocontext "context"
+	"time"
+
+	"golang.handcraftedbits.com/pipewerx/internal/event"
+)
+
+//
+// Public types
+//
+
+// WatchableFilesystem is implemented by Filesystems that can report changes as they happen, so that a Source that is
+// watching for changes (see SourceConfig.Watch) doesn't have to wait for its next listing.
+type WatchableFilesystem interface {
+	Filesystem
+
+	// Watch starts watching a path (in the same form as the paths passed to ListFiles()) and, if recurse is true,
+	// every directory under it.  A value is sent on the returned channel whenever something under the path may have
+	// changed.  Notifications are coalesced, so a single value can represent any number of changes.  The channel is
+	// closed once stop is closed or if changes can no longer be reported.
+	Watch(path string, recurse bool, stop <-chan struct{}) (<-chan struct{}, error)
+}
+
+//
+// Public constants
+//
+
+const (
+	DefaultWatchPollInterval = 10 * time.Second
+	DefaultWatchSettleTime   = 2 * time.Second
+)
+
+//
+// Private types
+//
+
+// A File that has been added or changed, but hasn't been produced since it hasn't settled yet.
+type pendingWatchedFile struct {
+	watchedFile
+
+	since time.Time
+}
+
+// The state of a Source that is watching for changes.
+type sourceWatch struct {
+	cancel      <-chan struct{}
+	changes     <-chan struct{}
+	context     Context
+	errorHelper *errorPolicyHelper
+	fs          Filesystem
+	pending     map[string]pendingWatchedFile
+	readContext gocontext.Context
+	root        string
+	send        func(res Result) bool
+	src         *source
+	waitContext gocontext.Context
+	watched     map[string]watchedFile
+}
+
+// Lists the root path right away and then whenever a change is reported or the poll interval or settle time elapses,
+// until the Source is cancelled or an error stops it.  watchPath is the absolute form of the root path, which is what
+// is watched.
+func (watch *sourceWatch) run(watchPath string) {
+	var err error
+	var pollInterval = watch.src.config.WatchPollInterval
+	var settleTime = watch.src.config.WatchSettleTime
+	var stop = make(chan struct{})
+
+	defer close(stop)
+
+	if pollInterval <= 0 {
+		pollInterval = DefaultWatchPollInterval
+	}
+
+	if settleTime <= 0 {
+		settleTime = DefaultWatchSettleTime
+	}
+
+	if fs, ok := watch.fs.(WatchableFilesystem); ok {
+		if watch.changes, err = fs.Watch(watchPath, watch.src.config.Recurse, stop); err != nil {
+			watch.context.Log().Warn().
+				Str("id", watch.src.config.ID).
+				Err(err).
+				Msg("unable to watch for changes, falling back to polling")
+		}
+	}
+
+	for {
+		var timer *time.Timer
+		var wait = pollInterval
+
+		// Errors are reported after every listing, since the Source won't finish.
+
+		if !watch.list(settleTime) || !watch.flushErrors() {
+			return
+		}
+
+		if len(watch.pending) > 0 && settleTime < wait {
+			wait = settleTime
+		}
+
+		timer = time.NewTimer(wait)
+
+		select {
+		case <-watch.cancel:
+			timer.Stop()
+
+			watch.sendCancelled()
+
+			return
+
+		case _, ok := <-watch.changes:
+			timer.Stop()
+
+			if !ok {
+				// Changes can no longer be reported, so rely on polling from now on.
+
+				watch.changes = nil
+			}
+
+		case <-timer.C:
+		}
+	}
+}
+
+// Sends the Result for any errors collected by the ErrorPolicy, returning false if the Source should stop.
+func (watch *sourceWatch) flushErrors() bool {
+	var res = watch.errorHelper.finish()
+
+	if res == nil {
+		return true
+	}
+
+	return watch.send(res)
+}
+
+// Lists the root path, producing every File that has been added or changed and has settled, and returns false if the
+// Source should stop.
+func (watch *sourceWatch) list(settleTime time.Duration) bool {
+	var complete = true
+	var err error
+	var f *file
+	var now = time.Now()
+	var res Result
+	var seen = make(map[string]bool)
+	var stepper *pathStepper
+	var stop bool
+
+	if err = waitForTokens(watch.waitContext, watch.src.listLimiter, 1); err == nil {
+		stepper, err = newPathStepper(watch.fs, watch.root, watch.src.config.Recurse)
+	}
+
+	if err != nil {
+		if watch.waitContext.Err() != nil {
+			watch.sendCancelled()
+
+			return false
+		}
+
+		res, stop = watch.errorHelper.handle(&result{
+			err: withSourceID(watch.src.config.ID, OperationStat, err),
+		})
+
+		return (res == nil || watch.send(res)) && !stop
+	}
+
+	stepper.listLimiter = watch.src.listLimiter
+	stepper.waitContext = watch.waitContext
+
+	for {
+		var current watchedFile
+		var key string
+
+		if f, err = stepper.nextFile(); f == nil && err == nil {
+			break
+		}
+
+		if err != nil {
+			if watch.waitContext.Err() != nil {
+				watch.sendCancelled()
+
+				return false
+			}
+
+			// Files that weren't listed might still exist, so they can't be forgotten.
+
+			complete = false
+
+			if res, stop = watch.errorHelper.handle(&result{
+				err: withSourceID(watch.src.config.ID, OperationList, err),
+			}); (res != nil && !watch.send(res)) || stop {
+				return false
+			}
+
+			continue
+		}
+
+		current = newWatchedFile(f)
+		key = f.path.String()
+		seen[key] = true
+
+		if previous, ok := watch.watched[key]; ok && previous.equal(current) {
+			delete(watch.pending, key)
+
+			continue
+		}
+
+		if pending, ok := watch.pending[key]; !ok || !pending.equal(current) {
+			watch.pending[key] = pendingWatchedFile{
+				since:       now,
+				watchedFile: current,
+			}
+
+			continue
+		} else if now.Sub(pending.since) < settleTime {
+			continue
+		}
+
+		if !watch.send(watch.src.newFileResult(watch.context, watch.readContext, f)) {
+			return false
+		}
+
+		delete(watch.pending, key)
+
+		watch.watched[key] = current
+	}
+
+	if complete {
+		// Forget Files that have been removed, so that they're produced again if they're recreated.
+
+		for key := range watch.watched {
+			if !seen[key] {
+				delete(watch.watched, key)
+			}
+		}
+
+		for key := range watch.pending {
+			if !seen[key] {
+				delete(watch.pending, key)
+			}
+		}
+	}
+
+	return true
+}
+
+func (watch *sourceWatch) sendCancelled() {
+	if event.IsAllowedFrom(componentSource) {
+		event.Send(sourceEventCancelled(watch.src.config.ID))
+	}
+}
+
+// The properties of a File that are used to determine whether it has changed.
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+func (watched watchedFile) equal(other watchedFile) bool {
+	return watched.size == other.size && watched.modTime.Equal(other.modTime)
+}
+
+//
+// Private functions
+//
+
+func newWatchedFile(f *file) watchedFile {
+	return watchedFile{
+		modTime: f.ModTime(),
+		size:    f.Size(),
+	}
+}
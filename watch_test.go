@@ -0,0 +1,299 @@
+package pipewerx // import "golang.handcraftedbits.com/pipewerx"
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//
+// Testcases
+//
+
+// Source watch tests
+
+var _ = g.Describe("Source", func() {
+	g.Describe("given a new instance that watches for changes", func() {
+		var cancel CancelFunc
+		var fs *watchMemFilesystem
+		var in <-chan Result
+		var modTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		var receivePath = func() string {
+			var res Result
+
+			Eventually(in).Should(Receive(&res))
+			Expect(res.Error()).To(BeNil())
+
+			return res.File().Path().String()
+		}
+
+		var startSource = func(config SourceConfig) {
+			var err error
+			var source Source
+
+			config.ID = "source"
+			config.Root = "/drop"
+			config.Watch = true
+
+			source, err = NewSource(config, fs)
+
+			Expect(err).To(BeNil())
+
+			in, cancel = source.Files(NewContext(ContextConfig{
+				Writer: ioutil.Discard,
+			}))
+		}
+
+		g.BeforeEach(func() {
+			fs = &watchMemFilesystem{
+				memFilesystem: &memFilesystem{
+					root: &memFilesystemNode{
+						children: map[string]*memFilesystemNode{
+							"drop": {
+								children: map[string]*memFilesystemNode{
+									"a.txt": {
+										contents: "a",
+										modTime:  modTime,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		g.AfterEach(func() {
+			cancel(nil)
+
+			for range in {
+			}
+		})
+
+		g.Context("using a Filesystem that must be polled", func() {
+			g.It("should produce Files that are added or changed once they settle", func() {
+				startSource(SourceConfig{
+					WatchPollInterval: 10 * time.Millisecond,
+					WatchSettleTime:   20 * time.Millisecond,
+				})
+
+				Expect(receivePath()).To(Equal("a.txt"))
+
+				fs.setFile("b.txt", "b", modTime)
+
+				Expect(receivePath()).To(Equal("b.txt"))
+
+				fs.setFile("a.txt", "changed", modTime)
+
+				Expect(receivePath()).To(Equal("a.txt"))
+				Consistently(in, 50*time.Millisecond).ShouldNot(Receive())
+			})
+
+			g.It("should produce Files again if they are removed and recreated", func() {
+				startSource(SourceConfig{
+					WatchPollInterval: 10 * time.Millisecond,
+					WatchSettleTime:   10 * time.Millisecond,
+				})
+
+				Expect(receivePath()).To(Equal("a.txt"))
+
+				fs.removeFile("a.txt")
+
+				Consistently(in, 50*time.Millisecond).ShouldNot(Receive())
+
+				fs.setFile("a.txt", "a", modTime)
+
+				Expect(receivePath()).To(Equal("a.txt"))
+			})
+
+			g.It("should not produce Files that haven't settled", func() {
+				startSource(SourceConfig{
+					WatchPollInterval: 10 * time.Millisecond,
+					WatchSettleTime:   time.Hour,
+				})
+
+				fs.setFile("b.txt", "b", modTime)
+
+				Consistently(in, 100*time.Millisecond).ShouldNot(Receive())
+			})
+
+			g.It("should not produce a File that is being written when the Source starts until it settles", func() {
+				var contents = "a"
+				var res Result
+
+				startSource(SourceConfig{
+					WatchPollInterval: 10 * time.Millisecond,
+					WatchSettleTime:   200 * time.Millisecond,
+				})
+
+				for i := 0; i < 10; i++ {
+					Expect(in).NotTo(Receive())
+
+					contents += "a"
+
+					fs.setFile("a.txt", contents, modTime)
+
+					time.Sleep(10 * time.Millisecond)
+				}
+
+				Eventually(in).Should(Receive(&res))
+				Expect(res.Error()).To(BeNil())
+				Expect(res.File().Path().String()).To(Equal("a.txt"))
+				Expect(res.File().Size()).To(Equal(int64(len(contents))))
+				Consistently(in, 100*time.Millisecond).ShouldNot(Receive())
+			})
+
+			g.It("should not use Checkpoints", func() {
+				startSource(SourceConfig{
+					Checkpoints: &memCheckpointStore{
+						checkpoints: make(map[string]*Checkpoint),
+						loadError:   errors.New("load"),
+					},
+					WatchPollInterval: 10 * time.Millisecond,
+					WatchSettleTime:   10 * time.Millisecond,
+				})
+
+				Expect(receivePath()).To(Equal("a.txt"))
+			})
+		})
+
+		g.Context("using a WatchableFilesystem", func() {
+			g.BeforeEach(func() {
+				fs.changes = make(chan struct{}, 1)
+			})
+
+			g.It("should produce Files when a change is reported", func() {
+				startSource(SourceConfig{
+					WatchPollInterval: time.Hour,
+					WatchSettleTime:   10 * time.Millisecond,
+				})
+
+				Expect(receivePath()).To(Equal("a.txt"))
+
+				fs.setFile("b.txt", "b", modTime)
+
+				fs.changes <- struct{}{}
+
+				Expect(receivePath()).To(Equal("b.txt"))
+				Expect(fs.watchedPath()).To(Equal("/drop"))
+			})
+		})
+	})
+})
+
+//
+// Private types
+//
+
+// In-memory Filesystem implementation that can be safely changed while it is being listed, and that implements
+// WatchableFilesystem if changes is set.
+type watchMemFilesystem struct {
+	*memFilesystem
+
+	changes chan struct{}
+	mutex   sync.Mutex
+	path    string
+}
+
+func (fs *watchMemFilesystem) ListFiles(path string) ([]os.FileInfo, error) {
+	var err error
+	var fileInfos []os.FileInfo
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fileInfos, err = fs.memFilesystem.ListFiles(path); err != nil {
+		return nil, err
+	}
+
+	// Copy the nodes, since they can change once the lock is released.
+
+	for i, fileInfo := range fileInfos {
+		fileInfos[i] = copyMemFilesystemNode(fileInfo.(*memFilesystemNode))
+	}
+
+	return fileInfos, nil
+}
+
+func (fs *watchMemFilesystem) ReadFile(path string) (io.ReadCloser, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	return fs.memFilesystem.ReadFile(path)
+}
+
+func (fs *watchMemFilesystem) StatFile(path string) (os.FileInfo, error) {
+	var err error
+	var fileInfo os.FileInfo
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fileInfo, err = fs.memFilesystem.StatFile(path); err != nil {
+		return nil, err
+	}
+
+	return copyMemFilesystemNode(fileInfo.(*memFilesystemNode)), nil
+}
+
+func (fs *watchMemFilesystem) Watch(path string, recurse bool, stop <-chan struct{}) (<-chan struct{}, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.path = path
+
+	if fs.changes == nil {
+		return nil, os.ErrInvalid
+	}
+
+	return fs.changes, nil
+}
+
+func (fs *watchMemFilesystem) removeFile(name string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	delete(fs.root.children["drop"].children, name)
+}
+
+func (fs *watchMemFilesystem) setFile(name, contents string, modTime time.Time) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.root.children["drop"].children[name] = &memFilesystemNode{
+		contents: contents,
+		modTime:  modTime,
+	}
+}
+
+func (fs *watchMemFilesystem) watchedPath() string {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	return fs.path
+}
+
+//
+// Private functions
+//
+
+func copyMemFilesystemNode(node *memFilesystemNode) *memFilesystemNode {
+	var copied = *node
+
+	if node.children != nil {
+		copied.children = make(map[string]*memFilesystemNode, len(node.children))
+
+		for name, child := range node.children {
+			copied.children[name] = child
+		}
+	}
+
+	return &copied
+}